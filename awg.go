@@ -2,9 +2,12 @@ package awg
 
 import (
 	"context"
-	"fmt"
+	"log"
+	"math/rand"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,35 +20,223 @@ const (
 	StatusTimeout
 	// StatusError means that job was broken by error in one task (if stopOnError is true)
 	StatusError
+	// StatusRunning means that WG is executing tasks right now
+	StatusRunning
+	// StatusCancelled means that job was broken by context cancellation
+	StatusCancelled
 
-	errTimeoutMessage = "Wait group timeout after %v"
-	stackBufferSize   = 1000
+	// CapacityUnbounded makes tasks channel hold all tasks, see SetCapacity
+	CapacityUnbounded = -1
 )
 
-// ErrorTimeout error on timeout
-type ErrorTimeout time.Duration
+// memoryCheckInterval is the interval of SetMaxMemoryMB checks
+var memoryCheckInterval = time.Second
 
-// Error implementation
-func (e ErrorTimeout) Error() string {
-	return fmt.Sprintf(errTimeoutMessage, time.Duration(e).String())
-}
+// SchedulingPolicy defines order of tasks dispatch
+type SchedulingPolicy int
+
+const (
+	// PolicyFIFO dispatches tasks in Add order
+	PolicyFIFO SchedulingPolicy = iota
+	// PolicyLIFO dispatches last added task first
+	PolicyLIFO
+	// PolicyRandom dispatches tasks in random order
+	PolicyRandom
+)
+
+// ChannelStrategy defines how tasks are passed to goroutines
+type ChannelStrategy int
+
+const (
+	// StrategyBuffered passes tasks through buffered channel (see SetCapacity)
+	StrategyBuffered ChannelStrategy = iota
+	// StrategyUnbuffered passes tasks through unbuffered channel
+	StrategyUnbuffered
+	// StrategyRingBuffer passes tasks through buffered channel dropping
+	// the oldest waiting task when a task read from external channel
+	// doesn't fit in it
+	StrategyRingBuffer
+)
 
 // WaitgroupFunc func
 type WaitgroupFunc func() error
 
+// WaitgroupFuncCtx func that gets waitgroup context
+type WaitgroupFuncCtx func(ctx context.Context) error
+
+// NewLimitedGroup makes waitgroup with tasks that run at most n at once.
+// The waitgroup is not started
+func NewLimitedGroup(n int, funcs ...WaitgroupFunc) *AdvancedWaitGroup {
+	var wg AdvancedWaitGroup
+	return wg.SetMaxConcurrency(n).MustAdd(funcs...)
+}
+
 // AdvancedWaitGroup enhanced wait group struct
 type AdvancedWaitGroup struct {
 	waitGroupStatus
 	stackBuffer []WaitgroupFunc
-	receiver    chan WaitgroupFunc
-	sender      chan WaitgroupFunc
-	capacity    uint32
+	details     []taskDetails
+	receiver    chan task
+	sender      chan task
+	capacity    int
 	length      int
 	timeout     *time.Duration
+	idleTimeout time.Duration
+	maxWait     time.Duration
+	minTasks    int
+	maxQueue    int
+	healthCheck func() bool
+	healthEvery time.Duration
+	maxMemory   float64
+	maxTaskTime time.Duration
+	onViolation func(index int, duration time.Duration)
+	exceeded    int64
+	withTimings bool
+	timings     []TaskTiming
+	timingsLock sync.Mutex
+	deadline    *time.Time
+	cancel      context.CancelFunc
+	parentCtx   context.Context
 	ctx         context.Context
-	done        func() <-chan struct{}
+	doneFn      func() <-chan struct{}
 	stopOnError bool
+	stopOnPanic bool
 	errors      []error
+	errorsLock  sync.RWMutex
+	aborted     error
+	sticky      bool
+	keys        map[string]bool
+	withErrors  bool
+	cancelAfter int
+	stats       waitGroupStats
+	observers   []taskObserver
+	workers     int
+	concurrency int
+	semaphore   Semaphore
+	breaker     CircuitBreaker
+	semCtx      context.Context
+	autoScaling *autoScaling
+	rampUp      *rampUp
+	limiter     *limiter
+	rate        float64
+	burst       int
+	throttle    *throttle
+	workerInit  func(workerID int)
+	workerExit  func(workerID int, err error)
+	afterStart  func()
+	onAbort     func(remainingTasks int)
+	onEarlyExit func(status int, errors []error)
+	onComplete  []func(index int, result interface{}, err error)
+	resultOf    func(index int) interface{}
+	ctxErr      error
+	errFeed     *errorFeed
+	cancelCh    chan struct{}
+	cancelOn    <-chan struct{}
+	globalStop  bool
+	errGroup    *errGroupAdapter
+	pool        *WorkerPool
+	retrier     *retrier
+	feeds       []<-chan WaitgroupFunc
+	values      map[interface{}]interface{}
+	valuesLock  sync.RWMutex
+	cancelErr   error
+	propagation func(parent context.Context) context.Context
+	seed        int64
+	sampling    float64
+	sampler     *rand.Rand
+	policy      SchedulingPolicy
+	strategy    ChannelStrategy
+	multiplier  float64
+	pacing      time.Duration
+	inOrder     bool
+	sorter      func(a, b NamedTask) bool
+	settings    *runSettings
+}
+
+// runSettings is a snapshot of settings taken by Start, so tasks that
+// outlive the run don't see settings changed by Reset or the next run
+type runSettings struct {
+	maxTaskTime time.Duration
+	onViolation func(index int, duration time.Duration)
+	breaker     CircuitBreaker
+	retrier     *retrier
+	ctx         context.Context
+	withTimings bool
+	onComplete  []func(index int, result interface{}, err error)
+	resultOf    func(index int) interface{}
+	taskCtx     context.Context
+	cancel      context.CancelFunc
+}
+
+// taskDetails keeps task settings given on add
+type taskDetails struct {
+	name     string
+	priority int
+	cleanup  func()
+}
+
+// WaitgroupFuncWithCleanup is a waitgroup function with cleanup that is called
+// whether the function runs or not
+type WaitgroupFuncWithCleanup struct {
+	Run     WaitgroupFunc
+	Cleanup func()
+}
+
+// Semaphore is the interface of golang.org/x/sync/semaphore.Weighted
+type Semaphore interface {
+	Acquire(ctx context.Context, n int64) error
+	Release(n int64)
+}
+
+// CircuitBreaker decides whether task may run and gets results of tasks
+// (see SetCircuitBreaker)
+type CircuitBreaker interface {
+	Allow() bool
+	ReportSuccess()
+	ReportFailure()
+}
+
+// NamedTask is a waitgroup function with name and priority,
+// so sorter can tell tasks apart
+type NamedTask struct {
+	Name     string
+	Priority int
+	F        WaitgroupFunc
+}
+
+// task is a waitgroup function with its position in Add order
+type task struct {
+	index    int
+	f        WaitgroupFunc
+	cleanup  func()
+	settings *runSettings
+	skip     bool
+}
+
+// taskObserver gets notified about each task execution, run is settings
+// of the run the task belongs to
+type taskObserver interface {
+	taskStarted(run *runSettings, index int)
+	taskFinished(run *runSettings, index int, d time.Duration, err error, panicked bool)
+}
+
+// groupObserver is optionally implemented by taskObserver
+// to get notified about waitgroup run
+type groupObserver interface {
+	groupStarted()
+	groupFinished(status int)
+}
+
+// contextObserver is optionally implemented by taskObserver
+// to pass values to context of running task
+type contextObserver interface {
+	taskContext(ctx context.Context, run *runSettings, index int) context.Context
+}
+
+// settingObserver is optionally implemented by taskObserver added by
+// a setting, it is replaced by the next call of the setting and removed by Reset
+type settingObserver interface {
+	setting() string
 }
 
 type waitGroupStatus struct {
@@ -53,7 +244,68 @@ type waitGroupStatus struct {
 	statusLock sync.RWMutex
 }
 
-func done() <-chan struct{} {
+type waitGroupStats struct {
+	total      int
+	success    int
+	failed     int
+	panicked   int
+	reported   int
+	leaked     int
+	undeliv    int
+	overwrote  int
+	running    int
+	startTime  time.Time
+	finishTime time.Time
+	stopped    bool
+	completed  []uint64
+	ordered    bool
+	next       int
+	held       map[int]error
+	stopCh     chan struct{}
+	lock       sync.RWMutex
+	// sendLock keeps results sent by report in index order
+	sendLock sync.Mutex
+}
+
+// Stats is a snapshot of waitgroup counters
+type Stats struct {
+	// Total is the number of tasks in the current run
+	Total int
+	// Success is the number of tasks that finished without error
+	Success int
+	// Error is the number of errors caught by execution process
+	Error int
+	// Panic is the number of tasks that panicked
+	Panic int
+	// Dropped is the number of errors that came after waitgroup stopped
+	Dropped int
+	// Overwritten is the number of tasks dropped by StrategyRingBuffer
+	Overwritten int
+	// Leaked is the number of tasks still running when MaxWaitAfterDone expired
+	Leaked int
+	// Undelivered is the number of errors that didn't fit into ErrCh
+	Undelivered int
+	// Running is the number of tasks executing right now
+	Running int
+	// Duration is the execution time (up to now if waitgroup still runs)
+	Duration time.Duration
+	// Status is the waitgroup status
+	Status int
+}
+
+// TaskTiming is execution time of task (see SetTrackTimings)
+type TaskTiming struct {
+	// TaskIndex is the position of task in Add order
+	TaskIndex int
+	// StartedAt is zero if task never ran
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+	// Err is the error returned by task
+	Err error
+}
+
+func defaultDone() <-chan struct{} {
 	return nil
 }
 
@@ -63,237 +315,2192 @@ func (wg *AdvancedWaitGroup) SetTimeout(t time.Duration) *AdvancedWaitGroup {
 	return wg
 }
 
+// SetCancelOnFirstSuccess makes waitgroup stop successfully as soon as
+// any task succeeds. Other tasks are abandoned
+func (wg *AdvancedWaitGroup) SetCancelOnFirstSuccess() *AdvancedWaitGroup {
+	return wg.SetCancelAfterSuccesses(1)
+}
+
+// SetCancelAfterSuccesses makes waitgroup stop successfully as soon as
+// n tasks succeed. Zero or negative n means waiting for all tasks (default)
+func (wg *AdvancedWaitGroup) SetCancelAfterSuccesses(n int) *AdvancedWaitGroup {
+	wg.cancelAfter = n
+	return wg
+}
+
+// SetStopOnFirstPanic make waitgroup stops if any task panics
+// whatever SetStopOnError is
+func (wg *AdvancedWaitGroup) SetStopOnFirstPanic() *AdvancedWaitGroup {
+	wg.stopOnPanic = true
+	return wg
+}
+
+// SetIdleTimeout makes waitgroup stop by timeout if no task finishes
+// within d since start or since the last finished task
+func (wg *AdvancedWaitGroup) SetIdleTimeout(d time.Duration) *AdvancedWaitGroup {
+	wg.idleTimeout = d
+	return wg
+}
+
+// MaxWaitAfterDone makes Start wait up to d for tasks that are still running
+// when waitgroup stops early, so their results are collected.
+// Tasks running after d are counted as leaked (see Stats)
+func (wg *AdvancedWaitGroup) MaxWaitAfterDone(d time.Duration) *AdvancedWaitGroup {
+	wg.maxWait = d
+	return wg
+}
+
+// SetMaxTaskDuration defines soft limit of task duration. Tasks that run
+// longer are not stopped, they are counted (see GetExceededDurationCount)
+func (wg *AdvancedWaitGroup) SetMaxTaskDuration(d time.Duration) *AdvancedWaitGroup {
+	wg.maxTaskTime = d
+	return wg
+}
+
+// OnTaskSLAViolation defines hook that is called for each task that runs
+// longer than SetMaxTaskDuration limit
+func (wg *AdvancedWaitGroup) OnTaskSLAViolation(fn func(index int, duration time.Duration)) *AdvancedWaitGroup {
+	wg.onViolation = fn
+	return wg
+}
+
+// SetTrackTimings makes waitgroup record execution time of each task
+// (see GetTimings). It is disabled by default because of per-task overhead
+func (wg *AdvancedWaitGroup) SetTrackTimings(b bool) *AdvancedWaitGroup {
+	wg.withTimings = b
+	return wg
+}
+
+// GetExceededDurationCount returns the number of tasks of the current run
+// that took longer than SetMaxTaskDuration limit
+func (wg *AdvancedWaitGroup) GetExceededDurationCount() int {
+	return int(atomic.LoadInt64(&wg.exceeded))
+}
+
+// SetMaxMemoryMB makes waitgroup stop with ErrMemoryExceeded when allocated
+// heap memory exceeds mb megabytes. Memory is checked every second while
+// waitgroup runs. Zero or negative mb means no limit (default)
+func (wg *AdvancedWaitGroup) SetMaxMemoryMB(mb float64) *AdvancedWaitGroup {
+	wg.maxMemory = mb
+	return wg
+}
+
+// SetHealthCheck makes waitgroup call fn every interval while it runs and
+// stop with ErrorHealthCheckFailed when fn returns false.
+// It replaces health check set before
+func (wg *AdvancedWaitGroup) SetHealthCheck(fn func() bool, interval time.Duration) *AdvancedWaitGroup {
+	wg.healthCheck = fn
+	wg.healthEvery = interval
+	return wg
+}
+
+// SetMinTasks makes Start fail with ErrInsufficientTasks and StatusError
+// if less than n tasks are added
+func (wg *AdvancedWaitGroup) SetMinTasks(n int) *AdvancedWaitGroup {
+	wg.minTasks = n
+	return wg
+}
+
 // SetStopOnError make wiatgroup stops if any task returns error
 func (wg *AdvancedWaitGroup) SetStopOnError(b bool) *AdvancedWaitGroup {
 	wg.stopOnError = b
 	return wg
 }
 
-// Add adds new task in waitgroup
-func (wg *AdvancedWaitGroup) Add(f ...WaitgroupFunc) *AdvancedWaitGroup {
-	wg.stackBuffer = append(wg.stackBuffer, f...)
+// SetMaxConcurrency limits number of tasks running at once.
+// Zero or negative n means no limit (default). It disables auto scaling
+func (wg *AdvancedWaitGroup) SetMaxConcurrency(n int) *AdvancedWaitGroup {
+	wg.concurrency = n
+	wg.autoScaling = nil
+	wg.rampUp = nil
+	wg.semaphore = nil
 	return wg
 }
 
-// AddSlice adds new tasks in waitgroup
-func (wg *AdvancedWaitGroup) AddSlice(s []WaitgroupFunc) *AdvancedWaitGroup {
-	return wg.Add(s...)
+// SetThrottleRate limits rate of tasks start to rps per second with bursts
+// up to burst tasks. Zero or negative rps means no limit (default)
+func (wg *AdvancedWaitGroup) SetThrottleRate(rps float64, burst int) *AdvancedWaitGroup {
+	wg.rate = rps
+	wg.burst = burst
+	return wg
 }
 
-// WithContext make wiatgroup work with context timeout and Done
-func (wg *AdvancedWaitGroup) WithContext(ctx context.Context) *AdvancedWaitGroup {
-	wg.ctx = ctx
-	wg.done = ctx.Done
+// SetCircuitBreaker makes each task ask cb before run. Task that is not
+// allowed fails with ErrCircuitOpen, results of other tasks are reported
+// to cb. Nil cb disables circuit breaking (default)
+func (wg *AdvancedWaitGroup) SetCircuitBreaker(cb CircuitBreaker) *AdvancedWaitGroup {
+	wg.breaker = cb
 	return wg
 }
 
-// SetCapacity defines tasks channel capacity
-func (wg *AdvancedWaitGroup) SetCapacity(c int) *AdvancedWaitGroup {
-	if c >= 0 {
-		wg.capacity = uint32(c)
-	}
+// SetSemaphore makes each task acquire s before run, so the limit can be
+// shared with other waitgroups and code. It replaces limit set by
+// SetMaxConcurrency and auto scaling
+func (wg *AdvancedWaitGroup) SetSemaphore(s Semaphore) *AdvancedWaitGroup {
+	wg.semaphore = s
+	wg.concurrency = 0
+	wg.autoScaling = nil
+	wg.rampUp = nil
 	return wg
 }
 
-// GetCapacity defines tasks channel capacity
-func (wg *AdvancedWaitGroup) GetCapacity() int {
-	return int(wg.capacity)
-}
+// SetConcurrencyAutoScaling makes waitgroup adjust number of tasks running at
+// once between min and max. Every 100ms the limit grows if all running slots
+// are busy and shrinks if less than targetUtilization of them are busy.
+// It replaces limit set by SetMaxConcurrency. Experimental
+func (wg *AdvancedWaitGroup) SetConcurrencyAutoScaling(min, max int, targetUtilization float64) *AdvancedWaitGroup {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
 
-func (wg *AdvancedWaitGroup) init() {
-	wg.setStatus(StatusSuccess)
-	if wg.done == nil {
-		wg.done = done
+	wg.concurrency = 0
+	wg.semaphore = nil
+	wg.rampUp = nil
+	wg.autoScaling = &autoScaling{
+		min:    min,
+		max:    max,
+		target: targetUtilization,
 	}
+	return wg
+}
 
-	wg.length = len(wg.stackBuffer)
-	cap := wg.length
-	if c := wg.GetCapacity(); c > 0 {
-		cap = c
+// SetConcurrencyRampUp makes waitgroup start with initial number of tasks
+// running at once and grow the limit linearly to max during rampDuration.
+// After that the limit stays max. It replaces limit set by SetMaxConcurrency
+// and auto scaling
+func (wg *AdvancedWaitGroup) SetConcurrencyRampUp(initial, max int, rampDuration time.Duration) *AdvancedWaitGroup {
+	if initial < 1 {
+		initial = 1
+	}
+	if max < initial {
+		max = initial
 	}
 
-	wg.receiver = make(chan WaitgroupFunc, cap)
-	wg.sender = make(chan WaitgroupFunc, wg.length)
-	for _, f := range wg.stackBuffer {
-		wg.sender <- f
+	wg.concurrency = 0
+	wg.semaphore = nil
+	wg.autoScaling = nil
+	wg.rampUp = &rampUp{
+		initial:  initial,
+		max:      max,
+		duration: rampDuration,
 	}
+	return wg
 }
 
-// Start runs tasks in separate goroutines
-func (wg *AdvancedWaitGroup) Start() *AdvancedWaitGroup {
-	if wg.CheckStatus(StatusSuccess) {
-		return wg
+// SetMinimumGoroutines makes waitgroup start n worker goroutines on Start
+// instead of spawning new goroutine per task. Workers wait for tasks even if
+// no task is ready yet and exit when waitgroup finishes
+func (wg *AdvancedWaitGroup) SetMinimumGoroutines(n int) *AdvancedWaitGroup {
+	if n >= 0 {
+		wg.workers = n
 	}
+	return wg
+}
 
-	wg.init()
+// SetWorkerInitFn defines function that is called once by each worker
+// goroutine at startup (see SetMinimumGoroutines). Workers get sequential IDs
+// starting from 0
+func (wg *AdvancedWaitGroup) SetWorkerInitFn(fn func(workerID int)) *AdvancedWaitGroup {
+	wg.workerInit = fn
+	return wg
+}
 
-	if wg.length > 0 {
-		failed := make(chan error, wg.length)
-		done := make(chan struct{}, wg.length)
-		wgDone := make(chan struct{})
+// SetWorkerExitFn defines function that is called by each worker goroutine
+// on exit (see SetMinimumGoroutines). Error is nil if worker finished normally.
+// If worker panicked, fn gets the panic as error and the panic goes on
+func (wg *AdvancedWaitGroup) SetWorkerExitFn(fn func(workerID int, err error)) *AdvancedWaitGroup {
+	wg.workerExit = fn
+	return wg
+}
 
-		var startTime time.Time
-		var timer <-chan time.Time
+// SetAfterStart defines hook that is called once by Start before any task runs.
+// If hook panics, waitgroup stops with StatusError and tasks don't run
+func (wg *AdvancedWaitGroup) SetAfterStart(fn func()) *AdvancedWaitGroup {
+	wg.afterStart = fn
+	return wg
+}
 
-		if wg.timeout != nil {
-			if *wg.timeout != 0 {
-				startTime = time.Now()
-			}
-			timer = time.After(*wg.timeout)
-		}
-		if wg.ctx != nil {
-			startTime = time.Now()
-		}
+// SetOnAbort defines hook that is called once by Start when waitgroup stops
+// before all tasks are done (by error, timeout or context). Hook gets
+// the number of tasks that were in the queue and will not run
+func (wg *AdvancedWaitGroup) SetOnAbort(fn func(remainingTasks int)) *AdvancedWaitGroup {
+	wg.onAbort = fn
+	return wg
+}
 
-		go func() {
-			for f := range wg.sender {
-				select {
-				case wg.receiver <- f:
-					// Nothing to do
-				case <-wgDone:
-					return
-				}
-			}
-		}()
+// SetOnTaskComplete adds hook that is called after each task with its index
+// (in Add order) and error. Result is nil, typed waitgroup passes task result
+// (zero value on error). Hooks are called in order they are added
+func (wg *AdvancedWaitGroup) SetOnTaskComplete(fn func(index int, result interface{}, err error)) *AdvancedWaitGroup {
+	wg.onComplete = append(wg.onComplete, fn)
+	return wg
+}
 
-	ForLoop:
-		for wg.length > 0 {
-			select {
-			case f := <-wg.receiver:
-				go func(f WaitgroupFunc, failed chan<- error, done chan<- struct{}) {
-					if wg.stopOnError {
-						wg.doIfSuccess(f, failed, done)
-						return
-					}
+// SetEarlyExitHook defines hook that is called by Start the moment waitgroup
+// stops by error, timeout or cancellation, before waiting for running tasks.
+// Hook gets current status and errors
+func (wg *AdvancedWaitGroup) SetEarlyExitHook(fn func(status int, errors []error)) *AdvancedWaitGroup {
+	wg.onEarlyExit = fn
+	return wg
+}
 
-					wg.do(f, failed, done)
+// SetSeed makes waitgroup shuffle tasks with seeded PRNG before dispatch,
+// so dispatch order is reproducible. Zero seed (default) keeps the order of
+// scheduling policy. With PolicyLIFO the seed is ignored
+func (wg *AdvancedWaitGroup) SetSeed(seed int64) *AdvancedWaitGroup {
+	wg.seed = seed
+	return wg
+}
 
-				}(f, failed, done)
-			case err := <-failed:
-				wg.errors = append(wg.errors, err)
-				wg.length--
-				if wg.stopOnError {
-					wg.setStatus(StatusError)
-					break ForLoop
-				}
-			case <-done:
-				wg.length--
-			case <-wg.done():
-				if deadlineTime, ok := wg.ctx.Deadline(); ok {
-					wg.errors = append(wg.errors, ErrorTimeout(deadlineTime.Sub(startTime)))
-					wg.setStatus(StatusTimeout)
-				}
-				break ForLoop
-			case t := <-timer:
-				d := t.Sub(startTime)
-				wg.errors = append(wg.errors, ErrorTimeout(d))
-				wg.setStatus(StatusTimeout)
-				break ForLoop
-			}
-		}
+// SetSamplingRate makes waitgroup run only random rate part of tasks
+// (e.g. 0.1 for about 10%). Skipped tasks are counted as successful.
+// Decisions are reproducible if SetSeed is called. Zero rate (default)
+// or rate >= 1 runs all tasks
+func (wg *AdvancedWaitGroup) SetSamplingRate(rate float64) *AdvancedWaitGroup {
+	wg.sampling = rate
+	return wg
+}
 
-		close(wgDone)
-		close(wg.sender)
+// SetChannelStrategy defines how tasks are passed to goroutines,
+// StrategyBuffered by default. StrategyRingBuffer drops tasks only when
+// workers (see SetMinimumGoroutines) are slower than external channels
+// (see SetChannel). Dropped tasks are counted only as overwritten (see Stats)
+func (wg *AdvancedWaitGroup) SetChannelStrategy(strategy ChannelStrategy) *AdvancedWaitGroup {
+	wg.strategy = strategy
+	return wg
+}
+
+// SetSchedulingPolicy defines order of tasks dispatch, PolicyFIFO by default
+func (wg *AdvancedWaitGroup) SetSchedulingPolicy(policy SchedulingPolicy) *AdvancedWaitGroup {
+	wg.policy = policy
+	return wg
+}
+
+// InOrder makes wg collect results of tasks in Add order: tasks still run
+// in parallel, but result of task is processed (error is stored, stop on
+// error is checked etc.) after results of all previous tasks
+func InOrder(wg *AdvancedWaitGroup) *AdvancedWaitGroup {
+	wg.inOrder = true
+	return wg
+}
+
+// SetDispatchPacing makes waitgroup wait d between dispatch of queued
+// tasks. Waiting stops when waitgroup stops. Zero d means no delay (default)
+func (wg *AdvancedWaitGroup) SetDispatchPacing(d time.Duration) *AdvancedWaitGroup {
+	wg.pacing = d
+	return wg
+}
+
+// SetDispatchOrder makes waitgroup dispatch last added task first if reverse
+// is true (PolicyLIFO) and in Add order otherwise (PolicyFIFO)
+func (wg *AdvancedWaitGroup) SetDispatchOrder(reverse bool) *AdvancedWaitGroup {
+	if reverse {
+		return wg.SetSchedulingPolicy(PolicyLIFO)
 	}
+	return wg.SetSchedulingPolicy(PolicyFIFO)
+}
 
+// SetSorter makes waitgroup sort tasks with less before dispatch.
+// Sorter overrides scheduling policy and seed
+func (wg *AdvancedWaitGroup) SetSorter(less func(a, b WaitgroupFunc) bool) *AdvancedWaitGroup {
+	return wg.SetSorterNamed(func(a, b NamedTask) bool {
+		return less(a.F, b.F)
+	})
+}
+
+// SetSorterNamed is like SetSorter but less gets tasks with names given by
+// AddNamed and priorities given by AddWithPriority. Tasks added by Add have
+// empty names and zero priority
+func (wg *AdvancedWaitGroup) SetSorterNamed(less func(a, b NamedTask) bool) *AdvancedWaitGroup {
+	wg.sorter = less
 	return wg
 }
 
-func (wg *AdvancedWaitGroup) do(f WaitgroupFunc, failed chan<- error, done chan<- struct{}) {
-	// Handle panic and pack it into stdlib error
-	defer func() {
-		if r := recover(); r != nil {
-			buf := make([]byte, stackBufferSize)
-			count := runtime.Stack(buf, false)
-			failed <- fmt.Errorf("Panic handeled\n%v\n%s", r, buf[:count])
-		}
-	}()
+// SetPrioritizer is like SetSorterNamed but takes comparison function:
+// negative result means that a runs first
+func (wg *AdvancedWaitGroup) SetPrioritizer(cmp func(a, b NamedTask) int) *AdvancedWaitGroup {
+	return wg.SetSorterNamed(func(a, b NamedTask) bool {
+		return cmp(a, b) < 0
+	})
+}
 
-	if err := f(); err != nil {
-		failed <- err
-		return
+// SetMaxQueueDepth limits number of tasks in waitgroup, Add returns
+// ErrQueueFull if tasks don't fit. Zero or negative n means no limit (default)
+func (wg *AdvancedWaitGroup) SetMaxQueueDepth(n int) *AdvancedWaitGroup {
+	wg.maxQueue = n
+	return wg
+}
+
+// Add adds new task in waitgroup and returns number of added tasks.
+// It returns ErrAlreadyStarted if waitgroup is started (until Reset)
+// and ErrQueueFull if tasks exceed SetMaxQueueDepth limit. Tasks are added
+// all or none, so no task is added on error. All Add methods return
+// the same way
+func (wg *AdvancedWaitGroup) Add(f ...WaitgroupFunc) (int, *AdvancedWaitGroup, error) {
+	if err := wg.add(f, nil); err != nil {
+		return 0, wg, err
 	}
+	return len(f), wg, nil
+}
 
-	done <- struct{}{}
+// checkAdd returns error if n tasks can't be added, statusLock must be held
+func (wg *AdvancedWaitGroup) checkAdd(n int) error {
+	if wg.status != StatusIdle {
+		return ErrAlreadyStarted
+	}
+	if wg.maxQueue > 0 && len(wg.stackBuffer)+n > wg.maxQueue {
+		return ErrQueueFull
+	}
+	return nil
 }
 
-func (wg *AdvancedWaitGroup) doIfSuccess(f WaitgroupFunc, failed chan<- error, done chan<- struct{}) {
-	// Handle panic and pack it into stdlib error
-	defer func() {
-		if r := recover(); r != nil {
-			buf := make([]byte, stackBufferSize)
-			count := runtime.Stack(buf, false)
-			failed <- fmt.Errorf("Panic handeled\n%v\n%s", r, buf[:count])
-		}
-	}()
+// add appends tasks with their details (zero details if d is nil) unless
+// checkAdd fails. It holds statusLock, so Start takes all tasks added before
+func (wg *AdvancedWaitGroup) add(f []WaitgroupFunc, d []taskDetails) error {
+	wg.statusLock.Lock()
+	defer wg.statusLock.Unlock()
 
-	// Check stop on error
-	if !wg.CheckStatus(StatusSuccess) {
-		// If some other goroutine get an error
-		done <- struct{}{}
-		return
+	if err := wg.checkAdd(len(f)); err != nil {
+		return err
+	}
+	if d == nil {
+		d = make([]taskDetails, len(f))
 	}
+	wg.stackBuffer = append(wg.stackBuffer, f...)
+	wg.details = append(wg.details, d...)
+	return nil
+}
 
-	if err := f(); err != nil {
-		failed <- err
-		return
+// AddWithPriority adds new tasks with priority in waitgroup.
+// Priority is used by sorter (see SetPrioritizer)
+func (wg *AdvancedWaitGroup) AddWithPriority(priority int, f ...WaitgroupFunc) (int, *AdvancedWaitGroup, error) {
+	d := make([]taskDetails, len(f))
+	for i := range d {
+		d[i].priority = priority
 	}
 
-	done <- struct{}{}
+	if err := wg.add(f, d); err != nil {
+		return 0, wg, err
+	}
+	return len(f), wg, nil
 }
 
-// Reset performs cleanup task queue and reset state
-func (wg *AdvancedWaitGroup) Reset() {
-	wg.stackBuffer = []WaitgroupFunc{}
-	wg.receiver = nil
-	wg.sender = nil
-	wg.timeout = nil
-	wg.stopOnError = false
-	wg.setStatus(StatusIdle)
+// AddWithCleanup adds new tasks with cleanup in waitgroup. Cleanup is called
+// after task is done or when task is abandoned because waitgroup stopped
+func (wg *AdvancedWaitGroup) AddWithCleanup(r ...WaitgroupFuncWithCleanup) (int, *AdvancedWaitGroup, error) {
+	f := make([]WaitgroupFunc, len(r))
+	d := make([]taskDetails, len(r))
+	for i, t := range r {
+		f[i], d[i] = t.Run, taskDetails{cleanup: t.Cleanup}
+	}
 
-	// pool
-	wg.errors = []error{}
+	if err := wg.add(f, d); err != nil {
+		return 0, wg, err
+	}
+	return len(r), wg, nil
 }
 
-// GetLastError returns last error that caught by execution process
-func (wg *AdvancedWaitGroup) GetLastError() error {
-	if l := len(wg.errors); l > 0 {
-		return wg.errors[l-1]
+// AddNamed adds new named tasks in waitgroup
+func (wg *AdvancedWaitGroup) AddNamed(t ...NamedTask) (int, *AdvancedWaitGroup, error) {
+	f := make([]WaitgroupFunc, len(t))
+	d := make([]taskDetails, len(t))
+	for i, nt := range t {
+		f[i], d[i] = nt.F, taskDetails{name: nt.Name, priority: nt.Priority}
 	}
-	return nil
+
+	if err := wg.add(f, d); err != nil {
+		return 0, wg, err
+	}
+	return len(t), wg, nil
 }
 
-// GetAllErrors returns all errors that caught by execution process
-func (wg *AdvancedWaitGroup) GetAllErrors() []error {
-	return wg.errors
+// AddOnce adds new task in waitgroup unless task with the same key is added
+// already (until Reset). It returns true if task is added
+func (wg *AdvancedWaitGroup) AddOnce(key string, f WaitgroupFunc) bool {
+	wg.statusLock.Lock()
+	defer wg.statusLock.Unlock()
+
+	if wg.keys[key] || wg.checkAdd(1) != nil {
+		return false
+	}
+
+	if wg.keys == nil {
+		wg.keys = map[string]bool{}
+	}
+	wg.keys[key] = true
+	wg.stackBuffer = append(wg.stackBuffer, f)
+	wg.details = append(wg.details, taskDetails{})
+	return true
 }
 
-func (wg *AdvancedWaitGroup) setStatus(status int) {
-	if status < StatusIdle || status > StatusError {
-		return
+// MustAdd is like Add but panics on error
+func (wg *AdvancedWaitGroup) MustAdd(f ...WaitgroupFunc) *AdvancedWaitGroup {
+	if _, _, err := wg.Add(f...); err != nil {
+		panic(err)
 	}
+	return wg
+}
 
-	wg.statusLock.Lock()
-	wg.status = status
-	wg.statusLock.Unlock()
+// AddSlice adds new tasks in waitgroup and returns number of added tasks
+func (wg *AdvancedWaitGroup) AddSlice(s []WaitgroupFunc) (int, *AdvancedWaitGroup, error) {
+	return wg.Add(s...)
 }
 
-// Status return result state string
-func (wg *AdvancedWaitGroup) Status() int {
-	wg.statusLock.RLock()
-	defer wg.statusLock.RUnlock()
+// AddSliceWithIndex adds tasks of s transformed by fn in waitgroup
+func (wg *AdvancedWaitGroup) AddSliceWithIndex(s []WaitgroupFunc, fn func(i int, f WaitgroupFunc) WaitgroupFunc) (int, *AdvancedWaitGroup, error) {
+	tasks := make([]WaitgroupFunc, len(s))
+	for i, f := range s {
+		tasks[i] = fn(i, f)
+	}
+	return wg.Add(tasks...)
+}
 
-	return wg.status
+// Filter returns tasks of s which indices satisfy pred
+func Filter(s []WaitgroupFunc, pred func(int) bool) []WaitgroupFunc {
+	result := make([]WaitgroupFunc, 0, len(s))
+	for i, f := range s {
+		if pred(i) {
+			result = append(result, f)
+		}
+	}
+	return result
 }
 
-// CheckStatus return result of status compare
-func (wg *AdvancedWaitGroup) CheckStatus(status int) bool {
-	if status < StatusIdle || status > StatusError {
-		return false
+// AddCtx adds new tasks that get waitgroup context (see WithContext)
+// or background context if it is not set
+func (wg *AdvancedWaitGroup) AddCtx(f ...WaitgroupFuncCtx) (int, *AdvancedWaitGroup, error) {
+	return wg.AddSliceCtx(f)
+}
+
+// AddSliceCtx adds new tasks that get waitgroup context
+func (wg *AdvancedWaitGroup) AddSliceCtx(s []WaitgroupFuncCtx) (int, *AdvancedWaitGroup, error) {
+	tasks := make([]WaitgroupFunc, len(s))
+	for i, f := range s {
+		index, f := len(wg.stackBuffer)+i, f
+		tasks[i] = func() error {
+			return f(wg.taskContext(index))
+		}
 	}
+	return wg.Add(tasks...)
+}
 
-	wg.statusLock.RLock()
-	defer wg.statusLock.RUnlock()
+// SetContextPropagation makes tasks added by AddCtx get context derived by
+// prop from waitgroup context instead of waitgroup context itself.
+// prop is called on each task run
+func (wg *AdvancedWaitGroup) SetContextPropagation(prop func(parent context.Context) context.Context) *AdvancedWaitGroup {
+	wg.propagation = prop
+	return wg
+}
 
-	return wg.status == status
+// taskContext returns context passed to i-th task, it carries waitgroup.
+// Context of a run is cancelled when the run is over, tasks called out of
+// a run (see Do) get waitgroup context
+func (wg *AdvancedWaitGroup) taskContext(index int) context.Context {
+	settings := wg.currentRun()
+
+	ctx := wg.runContext()
+	for _, o := range wg.observers {
+		if c, ok := o.(contextObserver); ok {
+			ctx = c.taskContext(ctx, settings, index)
+		}
+	}
+	if wg.propagation != nil {
+		ctx = wg.propagation(ctx)
+	}
+	return ctx
+}
+
+// AddFunc adds new tasks without return value in waitgroup
+func (wg *AdvancedWaitGroup) AddFunc(fn ...func()) (int, *AdvancedWaitGroup, error) {
+	return wg.AddFuncSlice(fn)
+}
+
+// AddFuncSlice adds new tasks without return value in waitgroup
+func (wg *AdvancedWaitGroup) AddFuncSlice(s []func()) (int, *AdvancedWaitGroup, error) {
+	tasks := make([]WaitgroupFunc, len(s))
+	for i, fn := range s {
+		fn := fn
+		tasks[i] = func() error {
+			fn()
+			return nil
+		}
+	}
+	return wg.Add(tasks...)
+}
+
+// WithContext make wiatgroup work with context timeout and Done.
+// It panics if ctx is nil
+func (wg *AdvancedWaitGroup) WithContext(ctx context.Context) *AdvancedWaitGroup {
+	if ctx == nil {
+		panic("awg: nil context passed to WithContext")
+	}
+	wg.ctx = ctx
+	wg.doneFn = ctx.Done
+	return wg
+}
+
+// WithDeadline make waitgroup stop by timeout at t. The deadline context is
+// derived from waitgroup context (or from Background one) and replaces it.
+// It overrides SetTimeout
+func (wg *AdvancedWaitGroup) WithDeadline(t time.Time) *AdvancedWaitGroup {
+	wg.dropDeadline()
+
+	parent := wg.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithDeadline(parent, t)
+	wg.parentCtx = wg.ctx
+	wg.deadline = &t
+	wg.cancel = cancel
+	return wg.WithContext(ctx)
+}
+
+// dropDeadline releases context made by WithDeadline and restores the previous one
+func (wg *AdvancedWaitGroup) dropDeadline() {
+	if wg.cancel == nil {
+		return
+	}
+
+	wg.cancel()
+	wg.ctx = wg.parentCtx
+	wg.doneFn = nil
+	if wg.ctx != nil {
+		wg.doneFn = wg.ctx.Done
+	}
+
+	wg.parentCtx = nil
+	wg.deadline = nil
+	wg.cancel = nil
+}
+
+// SetContextValue wraps waitgroup context with the key-value pair.
+// Background context is used if context is not set yet
+func (wg *AdvancedWaitGroup) SetContextValue(key, val interface{}) *AdvancedWaitGroup {
+	ctx := wg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return wg.WithContext(context.WithValue(ctx, key, val))
+}
+
+// WithValue stores the key-value pair in waitgroup. Unlike SetContextValue
+// it does not touch waitgroup context
+func (wg *AdvancedWaitGroup) WithValue(key, val interface{}) *AdvancedWaitGroup {
+	wg.valuesLock.Lock()
+	defer wg.valuesLock.Unlock()
+
+	if wg.values == nil {
+		wg.values = map[interface{}]interface{}{}
+	}
+	wg.values[key] = val
+	return wg
+}
+
+// Value returns value stored by WithValue or nil
+func (wg *AdvancedWaitGroup) Value(key interface{}) interface{} {
+	wg.valuesLock.RLock()
+	defer wg.valuesLock.RUnlock()
+	return wg.values[key]
+}
+
+// groupContextKey is the context key of waitgroup
+type groupContextKey struct{}
+
+// Context returns waitgroup context (background if not set) that carries
+// waitgroup itself, so tasks can get it by GroupFromContext
+func (wg *AdvancedWaitGroup) Context() context.Context {
+	ctx := wg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, groupContextKey{}, wg)
+}
+
+// GroupFromContext returns waitgroup of context made by Context or nil
+func GroupFromContext(ctx context.Context) *AdvancedWaitGroup {
+	wg, _ := ctx.Value(groupContextKey{}).(*AdvancedWaitGroup)
+	return wg
+}
+
+// SetCancellationError defines error that is stored when context is cancelled.
+// By default it is context.Canceled
+func (wg *AdvancedWaitGroup) SetCancellationError(err error) *AdvancedWaitGroup {
+	wg.cancelErr = err
+	return wg
+}
+
+// SetChannel makes waitgroup also run tasks read from receiver, so tasks
+// can be fed while waitgroup runs. Start returns when receiver is closed and
+// all tasks are done. It replaces channels set before by SetChannel or AddFuture
+func (wg *AdvancedWaitGroup) SetChannel(receiver <-chan WaitgroupFunc) *AdvancedWaitGroup {
+	wg.feeds = []<-chan WaitgroupFunc{receiver}
+	return wg
+}
+
+// AddFuture makes waitgroup also run tasks read from ch until it is closed.
+// Tasks can be read from several channels at once
+func (wg *AdvancedWaitGroup) AddFuture(ch <-chan WaitgroupFunc) *AdvancedWaitGroup {
+	wg.feeds = append(wg.feeds, ch)
+	return wg
+}
+
+// feed returns channel of tasks read from all feeds until stop
+func (wg *AdvancedWaitGroup) feed(stop <-chan struct{}) <-chan WaitgroupFunc {
+	switch len(wg.feeds) {
+	case 0:
+		return nil
+	case 1:
+		return wg.feeds[0]
+	}
+
+	// Merged channel buffers as many tasks as all feeds together
+	size := 0
+	for _, ch := range wg.feeds {
+		size += cap(ch)
+	}
+
+	merged := make(chan WaitgroupFunc, size)
+	var feeds sync.WaitGroup
+	feeds.Add(len(wg.feeds))
+	for _, ch := range wg.feeds {
+		go func(ch <-chan WaitgroupFunc) {
+			defer feeds.Done()
+			for f := range ch {
+				select {
+				case merged <- f:
+				case <-stop:
+					return
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		feeds.Wait()
+		close(merged)
+	}()
+	return merged
+}
+
+// SetCapacity defines tasks channel capacity:
+//
+//	c > 0                   channel holds at most c tasks
+//	c == 0                  default, channel holds all tasks
+//	c == CapacityUnbounded  channel holds all tasks whatever was set before
+//
+// Other negative values are ignored
+func (wg *AdvancedWaitGroup) SetCapacity(c int) *AdvancedWaitGroup {
+	if c >= CapacityUnbounded {
+		wg.capacity = c
+	}
+	return wg
+}
+
+// SetChannelBufferMultiplier makes tasks channel hold m times the number of
+// tasks (at least 1) instead of all tasks. Capacity set by SetCapacity takes
+// precedence. Zero or negative m means default
+func (wg *AdvancedWaitGroup) SetChannelBufferMultiplier(m float64) *AdvancedWaitGroup {
+	wg.multiplier = m
+	return wg
+}
+
+// SetUnbounded is an alias for SetCapacity(CapacityUnbounded)
+func (wg *AdvancedWaitGroup) SetUnbounded() *AdvancedWaitGroup {
+	return wg.SetCapacity(CapacityUnbounded)
+}
+
+// GetCapacity defines tasks channel capacity
+func (wg *AdvancedWaitGroup) GetCapacity() int {
+	return wg.capacity
+}
+
+// init makes waitgroup running and returns false if it is running or
+// succeeded already
+func (wg *AdvancedWaitGroup) init() bool {
+	// Tasks are taken under the lock Add takes, channels are ready
+	// when waitgroup becomes running
+	wg.statusLock.Lock()
+	if wg.status == StatusSuccess || wg.status == StatusRunning {
+		wg.statusLock.Unlock()
+		return false
+	}
+	wg.length = len(wg.stackBuffer)
+	wg.errFeed = newErrorFeed(wg.length+1, &wg.stats)
+	wg.cancelCh = make(chan struct{})
+	wg.status = StatusRunning
+	wg.statusLock.Unlock()
+
+	if wg.doneFn == nil {
+		wg.doneFn = defaultDone
+	}
+
+	wg.setContextError(nil)
+	settings := &runSettings{
+		maxTaskTime: wg.maxTaskTime,
+		onViolation: wg.onViolation,
+		breaker:     wg.breaker,
+		ctx:         wg.ctx,
+		withTimings: wg.withTimings,
+		onComplete:  append([]func(int, interface{}, error){}, wg.onComplete...),
+		resultOf:    wg.resultOf,
+	}
+	if wg.retrier != nil {
+		settings.retrier = wg.retrier.clone(wg.random().Int63())
+	}
+	// Context of tasks is cancelled when waitgroup is cancelled or finished
+	settings.taskCtx, settings.cancel = context.WithCancel(wg.Context())
+	wg.statusLock.Lock()
+	wg.settings = settings
+	wg.statusLock.Unlock()
+	atomic.StoreInt64(&wg.exceeded, 0)
+	if wg.sampling > 0 && wg.sampling < 1 {
+		wg.sampler = wg.random()
+	}
+	if wg.deadline != nil {
+		timeout := time.Until(*wg.deadline)
+		wg.timeout = &timeout
+	}
+
+	wg.stats.reset(wg.length, time.Now())
+	wg.stats.setOrdered(wg.inOrder)
+	wg.setAborted(-1)
+	wg.resetTimings()
+	cap := wg.length
+	if wg.multiplier > 0 && wg.capacity == 0 {
+		cap = int(wg.multiplier * float64(wg.length))
+		if cap < 1 {
+			cap = 1
+		}
+	}
+	if c := wg.GetCapacity(); c > 0 {
+		cap = c
+	}
+	if wg.strategy == StrategyUnbuffered {
+		cap = 0
+	}
+
+	wg.limiter = nil
+	wg.throttle = nil
+	if wg.rate > 0 {
+		wg.throttle = newThrottle(wg.rate, wg.burst)
+	}
+	if wg.concurrency > 0 {
+		wg.limiter = newLimiter(wg.concurrency)
+	}
+	if wg.autoScaling != nil {
+		wg.limiter = newLimiter(wg.autoScaling.min)
+	}
+	if wg.rampUp != nil {
+		wg.limiter = newLimiter(wg.rampUp.initial)
+	}
+
+	// Channels are read by GetQueuedCount while waitgroup runs
+	wg.statusLock.Lock()
+	wg.receiver = make(chan task, cap)
+	wg.sender = make(chan task, wg.length)
+	wg.statusLock.Unlock()
+	for _, t := range wg.dispatchOrder() {
+		wg.sender <- t
+	}
+	return true
+}
+
+// dispatchOrder returns tasks in order they should be dispatched
+func (wg *AdvancedWaitGroup) dispatchOrder() []task {
+	tasks := make([]task, len(wg.stackBuffer))
+	for i, f := range wg.stackBuffer {
+		tasks[i] = task{index: i, f: f, cleanup: wg.details[i].cleanup, settings: wg.settings, skip: !wg.sampled()}
+	}
+
+	switch {
+	case wg.sorter != nil:
+		sort.SliceStable(tasks, func(i, j int) bool {
+			a, b := wg.namedTask(tasks[i]), wg.namedTask(tasks[j])
+			return wg.sorter(a, b)
+		})
+	case wg.policy == PolicyLIFO:
+		stack := tasks
+		tasks = make([]task, 0, len(stack))
+		for len(stack) > 0 {
+			tasks = append(tasks, stack[len(stack)-1])
+			stack = stack[:len(stack)-1]
+		}
+	case wg.policy == PolicyRandom || wg.seed != 0:
+		r := wg.random()
+		r.Shuffle(len(tasks), func(i, j int) {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		})
+	}
+
+	return tasks
+}
+
+func (wg *AdvancedWaitGroup) namedTask(t task) NamedTask {
+	d := wg.details[t.index]
+	return NamedTask{Name: d.name, Priority: d.priority, F: t.f}
+}
+
+// random returns PRNG seeded by SetSeed or by current time
+func (wg *AdvancedWaitGroup) random() *rand.Rand {
+	seed := wg.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// Start runs tasks in separate goroutines
+func (wg *AdvancedWaitGroup) Start() *AdvancedWaitGroup {
+	if wg.errGroup != nil {
+		wg.startErrGroup()
+		return wg
+	}
+
+	if !wg.init() {
+		return wg
+	}
+	defer wg.errFeed.close()
+	defer wg.finishRun()
+
+	if wg.globalStop {
+		register(wg)
+		defer unregister(wg)
+	}
+
+	err := wg.checkMinTasks()
+	if err == nil {
+		err = wg.callAfterStart()
+	}
+	if err != nil {
+		wg.addError(err)
+		wg.setStatus(StatusError)
+		wg.stats.stop()
+		wg.setAborted(wg.length)
+		wg.callOnAbort(wg.length)
+		wg.drainQueue()
+		return wg
+	}
+
+	for _, o := range wg.observers {
+		if g, ok := o.(groupObserver); ok {
+			g.groupStarted()
+			defer func() {
+				g.groupFinished(wg.Status())
+			}()
+		}
+	}
+
+	if wg.length > 0 || len(wg.feeds) > 0 {
+		wgDone := make(chan struct{})
+
+		external := wg.feed(wgDone)
+		failed := make(chan error, wg.length)
+		done := make(chan struct{}, wg.length)
+
+		var startTime time.Time
+		var timer <-chan time.Time
+
+		if wg.timeout != nil {
+			if *wg.timeout != 0 {
+				startTime = time.Now()
+			}
+			timer = time.After(*wg.timeout)
+		}
+		if wg.ctx != nil {
+			startTime = time.Now()
+		}
+
+		var idle *time.Timer
+		var idleTimer <-chan time.Time
+		if wg.idleTimeout > 0 {
+			idle = time.NewTimer(wg.idleTimeout)
+			defer idle.Stop()
+			idleTimer = idle.C
+		}
+
+		forwarded := make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			wg.forward(done, wgDone)
+		}()
+
+		if wg.autoScaling != nil {
+			go wg.autoScaling.run(wg.limiter, wgDone)
+		}
+		if wg.rampUp != nil {
+			go wg.rampUp.run(wg.limiter, wgDone)
+		}
+
+		var unhealthy <-chan struct{}
+		if wg.healthCheck != nil && wg.healthEvery > 0 {
+			unhealthy = poll(wg.healthCheck, wg.healthEvery, wgDone)
+		}
+		var overMemory <-chan struct{}
+		if wg.maxMemory > 0 {
+			overMemory = poll(wg.checkMemory, memoryCheckInterval, wgDone)
+		}
+
+		if wg.semaphore != nil {
+			// Tasks waiting for semaphore are abandoned when waitgroup stops
+			var cancel context.CancelFunc
+			wg.semCtx, cancel = context.WithCancel(context.Background())
+			go func() {
+				<-wgDone
+				cancel()
+			}()
+		}
+
+		receiver := wg.receiver
+		if wg.workers > 0 {
+			// Pool mode: tasks are taken by workers, not by the loop
+			receiver = nil
+			for i := 0; i < wg.workers; i++ {
+				go wg.worker(i, wg.receiver, failed, done, wgDone)
+			}
+		}
+
+		succeeded := 0
+	ForLoop:
+		for wg.length > 0 || external != nil {
+			select {
+			case t := <-receiver:
+				wg.spawn(t, failed, done, wgDone)
+			case f, ok := <-external:
+				if !ok {
+					external = nil
+					continue
+				}
+				wg.length++
+				wg.dispatch(task{index: wg.stats.addTask(), f: f, settings: wg.settings, skip: !wg.sampled()}, failed, done, wgDone)
+			case err := <-failed:
+				wg.addError(err)
+				wg.length--
+				wg.stats.countFailed()
+				if _, panicked := err.(ErrorPanic); wg.stopOnError || (wg.stopOnPanic && panicked) {
+					wg.setStatus(StatusError)
+					break ForLoop
+				}
+				resetTimer(idle, wg.idleTimeout)
+			case <-done:
+				wg.length--
+				wg.stats.countSuccess()
+				if succeeded++; wg.cancelAfter > 0 && succeeded >= wg.cancelAfter {
+					break ForLoop
+				}
+				resetTimer(idle, wg.idleTimeout)
+			case <-wg.cancelCh:
+				wg.settings.cancel()
+				wg.addError(wg.cancellationError())
+				wg.setStatus(StatusCancelled)
+				break ForLoop
+			case <-wg.cancelOn:
+				wg.settings.cancel()
+				wg.addError(wg.cancellationError())
+				wg.setStatus(StatusCancelled)
+				break ForLoop
+			case <-unhealthy:
+				wg.addError(ErrorHealthCheckFailed{})
+				wg.setStatus(StatusError)
+				break ForLoop
+			case <-overMemory:
+				wg.addError(ErrMemoryExceeded)
+				wg.setStatus(StatusError)
+				break ForLoop
+			case <-idleTimer:
+				wg.addError(ErrorIdleTimeout(wg.idleTimeout))
+				wg.setStatus(StatusTimeout)
+				break ForLoop
+			case <-wg.doneFn():
+				ctxErr := wg.ctx.Err()
+				wg.setContextError(ctxErr)
+				if ctxErr == context.Canceled {
+					wg.addError(wg.cancellationError())
+					wg.setStatus(StatusCancelled)
+				} else if deadlineTime, ok := wg.ctx.Deadline(); ok {
+					wg.addError(ErrorTimeout(deadlineTime.Sub(startTime)))
+					wg.setStatus(StatusTimeout)
+				}
+				break ForLoop
+			case t := <-timer:
+				d := t.Sub(startTime)
+				wg.addError(ErrorTimeout(d))
+				wg.setStatus(StatusTimeout)
+				break ForLoop
+			}
+		}
+
+		if !wg.CheckStatus(StatusRunning) {
+			wg.setAborted(len(wg.sender) + len(wg.receiver))
+			if wg.onEarlyExit != nil {
+				wg.onEarlyExit(wg.Status(), wg.GetAllErrors())
+			}
+		}
+
+		aborted := wg.length > 0 || external != nil
+		if aborted {
+			wg.callOnAbort(len(wg.sender) + len(wg.receiver))
+		}
+		close(wgDone)
+		close(wg.sender)
+		if aborted && wg.maxWait > 0 {
+			wg.waitInFlight(failed, done)
+		}
+		// Tasks and forwarder don't wait for room in channels after stop
+		wg.stats.stop()
+		<-forwarded
+		wg.drainQueue()
+	} else {
+		wg.stats.stop()
+	}
+
+	if wg.CheckStatus(StatusRunning) {
+		wg.setStatus(StatusSuccess)
+	}
+
+	return wg
+}
+
+// runContext returns context of the current run or waitgroup context
+// out of a run
+func (wg *AdvancedWaitGroup) runContext() context.Context {
+	if settings := wg.currentRun(); settings != nil {
+		return settings.taskCtx
+	}
+	return wg.Context()
+}
+
+// currentRun returns settings of the current run or nil out of a run
+func (wg *AdvancedWaitGroup) currentRun() *runSettings {
+	wg.statusLock.RLock()
+	defer wg.statusLock.RUnlock()
+
+	return wg.settings
+}
+
+// finishRun cancels context of the run tasks and detaches it from waitgroup
+func (wg *AdvancedWaitGroup) finishRun() {
+	wg.statusLock.Lock()
+	settings := wg.settings
+	wg.settings = nil
+	wg.statusLock.Unlock()
+
+	settings.cancel()
+}
+
+// Cancel stops running waitgroup like cancellation of its context does.
+// It does nothing if waitgroup is not running
+func (wg *AdvancedWaitGroup) Cancel() {
+	wg.statusLock.Lock()
+	defer wg.statusLock.Unlock()
+
+	if wg.status != StatusRunning || wg.cancelCh == nil {
+		return
+	}
+
+	select {
+	case <-wg.cancelCh:
+		// Cancelled already
+	default:
+		close(wg.cancelCh)
+	}
+}
+
+// MustStart is like Start but panics if waitgroup has no tasks
+// or is running already
+func (wg *AdvancedWaitGroup) MustStart() *AdvancedWaitGroup {
+	if len(wg.stackBuffer) == 0 {
+		panic("awg: no tasks to start")
+	}
+	if wg.CheckStatus(StatusRunning) {
+		panic("awg: waitgroup is running already")
+	}
+	return wg.Start()
+}
+
+// waitInFlight collects results of running tasks up to maxWait
+func (wg *AdvancedWaitGroup) waitInFlight(failed <-chan error, done <-chan struct{}) {
+	timer := time.NewTimer(wg.maxWait)
+	defer timer.Stop()
+
+WaitLoop:
+	for wg.stats.inFlight() > 0 {
+		select {
+		case err := <-failed:
+			wg.addError(err)
+			wg.stats.countFailed()
+		case <-done:
+			wg.stats.countSuccess()
+		case <-timer.C:
+			wg.stats.leak()
+			break WaitLoop
+		}
+	}
+
+	// Results of tasks finished last are still in channels
+	for {
+		select {
+		case err := <-failed:
+			wg.addError(err)
+			wg.stats.countFailed()
+		case <-done:
+			wg.stats.countSuccess()
+		default:
+			return
+		}
+	}
+}
+
+// poll calls fn every interval until stop and returns channel
+// that is closed when fn returns false
+func poll(fn func() bool, interval time.Duration, stop <-chan struct{}) <-chan struct{} {
+	unhealthy := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if !fn() {
+					close(unhealthy)
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return unhealthy
+}
+
+// checkMemory reports whether allocated heap memory is within the limit
+func (wg *AdvancedWaitGroup) checkMemory() bool {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return float64(m.HeapAlloc) <= wg.maxMemory*1024*1024
+}
+
+// resetTimer restarts timer (if any) safely even if it has fired already
+func resetTimer(t *time.Timer, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+func (wg *AdvancedWaitGroup) cancellationError() error {
+	if wg.cancelErr != nil {
+		return wg.cancelErr
+	}
+	return context.Canceled
+}
+
+// worker runs tasks from receiver of its run until wgDone
+func (wg *AdvancedWaitGroup) worker(id int, receiver <-chan task, failed chan<- error, done chan<- struct{}, wgDone <-chan struct{}) {
+	if wg.workerExit != nil {
+		defer func() {
+			r := recover()
+			if r == nil {
+				wg.workerExit(id, nil)
+				return
+			}
+
+			wg.workerExit(id, panicError(r))
+			panic(r)
+		}()
+	}
+
+	if wg.workerInit != nil {
+		wg.workerInit(id)
+	}
+
+	for {
+		select {
+		case t := <-receiver:
+			wg.run(t, failed, done, wgDone)
+		case <-wgDone:
+			return
+		}
+	}
+}
+
+// spawn runs task in a new goroutine or by worker pool (see WorkerPool)
+func (wg *AdvancedWaitGroup) spawn(t task, failed chan<- error, done chan<- struct{}, wgDone <-chan struct{}) {
+	if wg.pool == nil {
+		go wg.run(t, failed, done, wgDone)
+		return
+	}
+
+	wg.pool.exec(func() {
+		wg.run(t, failed, done, wgDone)
+	}, func() {
+		wg.cleanup(t)
+	}, wgDone)
+}
+
+// forward moves tasks from queue to goroutines until stop
+func (wg *AdvancedWaitGroup) forward(done chan<- struct{}, stop <-chan struct{}) {
+	first := true
+	for t := range wg.sender {
+		if !first && !wg.pace(stop) {
+			wg.cleanup(t)
+			return
+		}
+		first = false
+
+		select {
+		case wg.receiver <- t:
+			// Nothing to do
+		case <-stop:
+			wg.cleanup(t)
+			return
+		}
+	}
+}
+
+// pace waits SetDispatchPacing delay. It returns false if stop is closed first
+func (wg *AdvancedWaitGroup) pace(stop <-chan struct{}) bool {
+	if wg.pacing <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(wg.pacing)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// forwardRing moves task to workers dropping the oldest waiting task
+// if channel is full. It is called by the waitgroup loop, so dropped
+// tasks are not waited for
+func (wg *AdvancedWaitGroup) forwardRing(t task, failed chan<- error, done chan<- struct{}, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			wg.cleanup(t)
+			return
+		case wg.receiver <- t:
+			return
+		default:
+		}
+
+		select {
+		case old := <-wg.receiver:
+			wg.length--
+			wg.cleanup(old)
+			wg.stats.overwrite(old.index, failed, done)
+		default:
+		}
+	}
+}
+
+// dispatch runs task read from external channel
+func (wg *AdvancedWaitGroup) dispatch(t task, failed chan<- error, done chan<- struct{}, wgDone <-chan struct{}) {
+	if wg.workers == 0 {
+		wg.spawn(t, failed, done, wgDone)
+		return
+	}
+	if wg.strategy == StrategyRingBuffer {
+		wg.forwardRing(t, failed, done, wgDone)
+		return
+	}
+
+	go func() {
+		select {
+		case wg.receiver <- t:
+		case <-wgDone:
+			wg.cleanup(t)
+		}
+	}()
+}
+
+func (wg *AdvancedWaitGroup) run(t task, failed chan<- error, done chan<- struct{}, wgDone <-chan struct{}) {
+	if t.skip {
+		wg.cleanup(t)
+		wg.stats.skipTask(t.index, done)
+		return
+	}
+
+	if wg.throttle != nil && !wg.throttle.wait(wgDone) {
+		wg.cleanup(t)
+		return
+	}
+	if wg.limiter != nil {
+		// Task is abandoned if waitgroup stops before it gets a slot
+		if !wg.limiter.acquire(wgDone) {
+			wg.cleanup(t)
+			return
+		}
+		defer wg.limiter.release()
+	}
+	if wg.semaphore != nil {
+		if wg.semaphore.Acquire(wg.semCtx, 1) != nil {
+			wg.cleanup(t)
+			return
+		}
+		defer wg.semaphore.Release(1)
+	}
+
+	if wg.stopOnError {
+		wg.doIfSuccess(t, failed, done)
+		return
+	}
+
+	wg.do(t, failed, done)
+}
+
+func (wg *AdvancedWaitGroup) do(t task, failed chan<- error, done chan<- struct{}) {
+	defer wg.cleanup(t)
+	wg.stats.startTask()
+	for _, o := range wg.observers {
+		o.taskStarted(t.settings, t.index)
+	}
+
+	startTime := time.Now()
+	err, panicked := wg.callWithBreaker(t)
+
+	d := time.Since(startTime)
+	if s := t.settings; s.maxTaskTime > 0 && d > s.maxTaskTime {
+		atomic.AddInt64(&wg.exceeded, 1)
+		if s.onViolation != nil {
+			s.onViolation(t.index, d)
+		}
+	}
+	for _, o := range wg.observers {
+		o.taskFinished(t.settings, t.index, d, err, panicked)
+	}
+	wg.callOnComplete(t, err)
+	if t.settings.withTimings {
+		wg.recordTiming(TaskTiming{
+			TaskIndex:  t.index,
+			StartedAt:  startTime,
+			FinishedAt: startTime.Add(d),
+			Duration:   d,
+			Err:        err,
+		})
+	}
+	wg.stats.finishTask(t.index, err, failed, done)
+}
+
+func (wg *AdvancedWaitGroup) doIfSuccess(t task, failed chan<- error, done chan<- struct{}) {
+	// Check stop on error
+	if !wg.CheckStatus(StatusRunning) {
+		// If some other goroutine get an error
+		wg.cleanup(t)
+		wg.stats.skipTask(t.index, done)
+		return
+	}
+
+	wg.do(t, failed, done)
+}
+
+// sampled reports whether task should run by sampling rate. It is called
+// by the waitgroup for each task before dispatch in index order, so
+// decisions don't depend on goroutine scheduling
+func (wg *AdvancedWaitGroup) sampled() bool {
+	if wg.sampling <= 0 || wg.sampling >= 1 {
+		return true
+	}
+
+	return wg.sampler.Float64() < wg.sampling
+}
+
+// cleanup calls task cleanup (if any), panic in cleanup is logged
+func (wg *AdvancedWaitGroup) cleanup(t task) {
+	if t.cleanup == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("awg: panic in cleanup of task %d: %v", t.index, r)
+		}
+	}()
+	t.cleanup()
+}
+
+// drainQueue calls cleanup of tasks left in the queue
+func (wg *AdvancedWaitGroup) drainQueue() {
+	for _, ch := range []chan task{wg.sender, wg.receiver} {
+	Drain:
+		for {
+			select {
+			case t, ok := <-ch:
+				if !ok {
+					break Drain
+				}
+				wg.cleanup(t)
+			default:
+				break Drain
+			}
+		}
+	}
+}
+
+// callWithBreaker executes task if circuit breaker allows it
+func (wg *AdvancedWaitGroup) callWithBreaker(t task) (err error, panicked bool) {
+	breaker := t.settings.breaker
+	if breaker == nil {
+		return wg.callWithRetry(t)
+	}
+
+	if !breaker.Allow() {
+		return ErrCircuitOpen, false
+	}
+
+	err, panicked = wg.callWithRetry(t)
+	if err != nil {
+		breaker.ReportFailure()
+	} else {
+		breaker.ReportSuccess()
+	}
+	return err, panicked
+}
+
+// call executes task and packs panic into stdlib error
+func (wg *AdvancedWaitGroup) call(f WaitgroupFunc) (err error, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			wg.stats.countPanic()
+			err, panicked = panicError(r), true
+		}
+	}()
+
+	return f(), false
+}
+
+// Do runs i-th task (in Add order) in current goroutine and returns its
+// error, panic is returned as ErrorPanic. Waitgroup status, errors and stats
+// are not affected. Cleanup of the task (see AddWithCleanup) is called after
+// it. It returns ErrNoSuchTask if there is no i-th task
+func (wg *AdvancedWaitGroup) Do(i int) (err error) {
+	if i < 0 || i >= len(wg.stackBuffer) {
+		return ErrNoSuchTask
+	}
+
+	defer wg.cleanup(task{index: i, cleanup: wg.details[i].cleanup})
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+
+	return wg.stackBuffer[i]()
+}
+
+func (wg *AdvancedWaitGroup) checkMinTasks() error {
+	if len(wg.stackBuffer) < wg.minTasks {
+		return ErrInsufficientTasks
+	}
+	return nil
+}
+
+func (wg *AdvancedWaitGroup) callAfterStart() (err error) {
+	if wg.afterStart == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+
+	wg.afterStart()
+	return nil
+}
+
+func (wg *AdvancedWaitGroup) callOnComplete(t task, err error) {
+	s := t.settings
+	if len(s.onComplete) == 0 {
+		return
+	}
+
+	var result interface{}
+	if s.resultOf != nil {
+		result = s.resultOf(t.index)
+	}
+	for _, fn := range s.onComplete {
+		fn(t.index, result, err)
+	}
+}
+
+func (wg *AdvancedWaitGroup) callOnAbort(remainingTasks int) {
+	if wg.onAbort != nil {
+		wg.onAbort(remainingTasks)
+	}
+}
+
+// Reset performs cleanup task queue and reset state
+func (wg *AdvancedWaitGroup) Reset() {
+	wg.stackBuffer = []WaitgroupFunc{}
+	wg.keys = nil
+	wg.details = nil
+	wg.feeds = nil
+	wg.statusLock.Lock()
+	wg.receiver = nil
+	wg.sender = nil
+	wg.settings = nil
+	wg.statusLock.Unlock()
+	wg.timeout = nil
+	wg.idleTimeout = 0
+	wg.maxWait = 0
+	wg.minTasks = 0
+	wg.maxQueue = 0
+	wg.healthCheck = nil
+	wg.retrier = nil
+	wg.pool = nil
+	wg.healthEvery = 0
+	wg.maxMemory = 0
+	wg.maxTaskTime = 0
+	wg.onViolation = nil
+	wg.withTimings = false
+	wg.dropDeadline()
+	wg.stopOnError = false
+	wg.cancelAfter = 0
+	wg.withErrors = false
+	wg.stopOnPanic = false
+	wg.workers = 0
+	wg.concurrency = 0
+	wg.rate = 0
+	wg.burst = 0
+	wg.semaphore = nil
+	wg.breaker = nil
+	wg.autoScaling = nil
+	wg.rampUp = nil
+	wg.workerInit = nil
+	wg.workerExit = nil
+	wg.afterStart = nil
+	wg.onAbort = nil
+	wg.onEarlyExit = nil
+	wg.onComplete = nil
+	wg.setContextError(nil)
+	wg.cancelErr = nil
+	wg.propagation = nil
+	wg.seed = 0
+	wg.sampling = 0
+	wg.policy = PolicyFIFO
+	wg.strategy = StrategyBuffered
+	wg.multiplier = 0
+	wg.pacing = 0
+	wg.inOrder = false
+	wg.sorter = nil
+	wg.globalStop = false
+	wg.dropSettingObservers()
+	wg.setStatus(StatusIdle)
+	wg.stats.reset(0, time.Time{})
+	wg.setAborted(-1)
+	wg.resetTimings()
+
+	// pool
+	wg.valuesLock.Lock()
+	wg.values = nil
+	wg.valuesLock.Unlock()
+
+	if !wg.sticky {
+		wg.errorsLock.Lock()
+		wg.errors = []error{}
+		wg.errorsLock.Unlock()
+	}
+}
+
+// SetStickyErrors makes Reset and Restart keep errors, so they accumulate
+// across runs until ClearErrors. Unlike other settings it is kept by Reset
+func (wg *AdvancedWaitGroup) SetStickyErrors(b bool) *AdvancedWaitGroup {
+	wg.sticky = b
+	return wg
+}
+
+// ClearErrors clears errors and counters of panics and dropped errors
+func (wg *AdvancedWaitGroup) ClearErrors() *AdvancedWaitGroup {
+	wg.errorsLock.Lock()
+	wg.errors = []error{}
+	wg.errorsLock.Unlock()
+
+	wg.stats.lock.Lock()
+	wg.stats.panicked = 0
+	wg.stats.reported = wg.stats.failed
+	wg.stats.lock.Unlock()
+	return wg
+}
+
+// Restart runs the same tasks again with the same settings. Unlike Reset it
+// clears only status and errors. It does nothing if waitgroup is running
+func (wg *AdvancedWaitGroup) Restart() *AdvancedWaitGroup {
+	wg.statusLock.Lock()
+	if wg.status == StatusRunning {
+		wg.statusLock.Unlock()
+		return wg
+	}
+	wg.status = StatusIdle
+	wg.statusLock.Unlock()
+
+	if !wg.sticky {
+		wg.errorsLock.Lock()
+		wg.errors = []error{}
+		wg.errorsLock.Unlock()
+	}
+	return wg.Start()
+}
+
+// GetLastError returns last error that caught by execution process
+func (wg *AdvancedWaitGroup) GetLastError() error {
+	wg.errorsLock.RLock()
+	defer wg.errorsLock.RUnlock()
+
+	if l := len(wg.errors); l > 0 {
+		return wg.errors[l-1]
+	}
+	return nil
+}
+
+// ErrCh returns channel that gets errors of the last run one by one as they
+// are caught by execution process. The channel buffers as many errors as
+// there are tasks added before Start (plus one), errors of tasks fed by
+// AddFuture or SetChannel are queued while the run lasts. Errors that don't fit
+// into the channel when Start returns are counted as undelivered (see Stats).
+// The channel is closed when Start returns, it is nil before the first Start
+func (wg *AdvancedWaitGroup) ErrCh() <-chan error {
+	wg.statusLock.RLock()
+	defer wg.statusLock.RUnlock()
+
+	if wg.errFeed == nil {
+		return nil
+	}
+	return wg.errFeed.ch
+}
+
+// setObserver adds o or replaces observer added by the same setting
+func (wg *AdvancedWaitGroup) setObserver(o taskObserver) {
+	if s, ok := o.(settingObserver); ok {
+		for i, prev := range wg.observers {
+			if p, ok := prev.(settingObserver); ok && p.setting() == s.setting() {
+				wg.observers[i] = o
+				return
+			}
+		}
+	}
+	wg.observers = append(wg.observers, o)
+}
+
+// dropSettingObservers removes observers added by settings
+func (wg *AdvancedWaitGroup) dropSettingObservers() {
+	observers := wg.observers[:0]
+	for _, o := range wg.observers {
+		if _, ok := o.(settingObserver); !ok {
+			observers = append(observers, o)
+		}
+	}
+	wg.observers = observers
+}
+
+// addError stores error caught by execution process
+func (wg *AdvancedWaitGroup) addError(err error) {
+	wg.errorsLock.Lock()
+	wg.errors = append(wg.errors, err)
+	wg.errorsLock.Unlock()
+
+	wg.statusLock.RLock()
+	feed := wg.errFeed
+	wg.statusLock.RUnlock()
+	if feed != nil {
+		feed.add(err)
+	}
+}
+
+// errorFeed queues errors of a run for ErrCh, it forwards them
+// until the run is finished
+type errorFeed struct {
+	lock     sync.Mutex
+	errors   []error
+	notify   chan struct{}
+	done     chan struct{}
+	finished chan struct{}
+	ch       chan error
+	stats    *waitGroupStats
+}
+
+func newErrorFeed(size int, stats *waitGroupStats) *errorFeed {
+	f := &errorFeed{
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		ch:       make(chan error, size),
+		stats:    stats,
+	}
+	go f.forward()
+	return f
+}
+
+func (f *errorFeed) add(err error) {
+	f.lock.Lock()
+	f.errors = append(f.errors, err)
+	f.lock.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// close finishes the run and waits until channel is closed
+func (f *errorFeed) close() {
+	close(f.done)
+	<-f.finished
+}
+
+// forward sends queued errors in order until the run is finished
+func (f *errorFeed) forward() {
+	defer close(f.finished)
+
+	for sent := 0; ; {
+		f.lock.Lock()
+		pending := f.errors[sent:]
+		f.lock.Unlock()
+
+		for _, err := range pending {
+			select {
+			case f.ch <- err:
+				sent++
+			case <-f.done:
+				f.flush(sent)
+				return
+			}
+		}
+		if len(pending) > 0 {
+			continue
+		}
+
+		select {
+		case <-f.notify:
+		case <-f.done:
+			f.flush(sent)
+			return
+		}
+	}
+}
+
+// flush puts errors left in queue into channel without waiting for the reader
+// and closes channel
+func (f *errorFeed) flush(sent int) {
+	f.lock.Lock()
+	pending := f.errors[sent:]
+	f.lock.Unlock()
+
+	for _, err := range pending {
+		select {
+		case f.ch <- err:
+		default:
+			f.stats.undeliver()
+		}
+	}
+	close(f.ch)
+}
+
+// GetAbortedError returns ErrGroupAborted if waitgroup stopped early
+// by error, timeout or cancellation, nil otherwise
+func (wg *AdvancedWaitGroup) GetAbortedError() error {
+	wg.errorsLock.RLock()
+	defer wg.errorsLock.RUnlock()
+
+	return wg.aborted
+}
+
+// setAborted stores ErrGroupAborted by current status, nil if skipped < 0
+func (wg *AdvancedWaitGroup) setAborted(skipped int) {
+	var err error
+	if skipped >= 0 {
+		err = ErrGroupAborted{Reason: abortReason(wg.Status()), TasksSkipped: skipped}
+	}
+
+	wg.errorsLock.Lock()
+	wg.aborted = err
+	wg.errorsLock.Unlock()
+}
+
+// GetContextError returns context error if waitgroup is stopped by context
+func (wg *AdvancedWaitGroup) GetContextError() error {
+	wg.errorsLock.RLock()
+	defer wg.errorsLock.RUnlock()
+
+	return wg.ctxErr
+}
+
+// setContextError stores context error that stopped waitgroup
+func (wg *AdvancedWaitGroup) setContextError(err error) {
+	wg.errorsLock.Lock()
+	wg.ctxErr = err
+	wg.errorsLock.Unlock()
+}
+
+// GetAllErrors returns copy of all errors that caught by execution process
+func (wg *AdvancedWaitGroup) GetAllErrors() []error {
+	wg.errorsLock.RLock()
+	defer wg.errorsLock.RUnlock()
+
+	result := make([]error, len(wg.errors))
+	copy(result, wg.errors)
+	return result
+}
+
+// WrapError applies wrap to all errors caught by waitgroup and stores
+// the results in waitgroup instead of original errors. Errors wrapped to nil
+// are removed. wrap is called under the lock of errors, so it shouldn't
+// call waitgroup methods
+func WrapError(wg *AdvancedWaitGroup, wrap func(error) error) []error {
+	wg.errorsLock.Lock()
+	defer wg.errorsLock.Unlock()
+
+	errs := make([]error, 0, len(wg.errors))
+	for _, err := range wg.errors {
+		if err = wrap(err); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	wg.errors = errs
+	return errs
+}
+
+// GetStats returns snapshot of all waitgroup counters taken at once
+func (wg *AdvancedWaitGroup) GetStats() Stats {
+	wg.stats.lock.RLock()
+	defer wg.stats.lock.RUnlock()
+
+	st := Stats{
+		Total:   wg.stats.total,
+		Success: wg.stats.success,
+		Error:   wg.stats.failed,
+		Panic:   wg.stats.panicked,
+		Dropped: wg.stats.dropped(),
+		Leaked:  wg.stats.leaked,
+		Running: wg.stats.running,
+		Status:  wg.Status(),
+
+		Overwritten: wg.stats.overwrote,
+		Undelivered: wg.stats.undeliv,
+	}
+
+	switch {
+	case wg.stats.startTime.IsZero():
+	case wg.stats.finishTime.IsZero():
+		st.Duration = time.Since(wg.stats.startTime)
+	default:
+		st.Duration = wg.stats.finishTime.Sub(wg.stats.startTime)
+	}
+
+	return st
+}
+
+// GetQueuedCount returns the number of tasks of running waitgroup
+// that wait for goroutine
+func (wg *AdvancedWaitGroup) GetQueuedCount() int {
+	wg.statusLock.RLock()
+	defer wg.statusLock.RUnlock()
+
+	return len(wg.sender) + len(wg.receiver)
+}
+
+// GetRunningCount returns the number of tasks executing right now
+func (wg *AdvancedWaitGroup) GetRunningCount() int {
+	return wg.stats.inFlight()
+}
+
+// GetCompletedTasks returns sorted indices (in Add order) of tasks
+// that finished successfully before waitgroup stopped
+func (wg *AdvancedWaitGroup) GetCompletedTasks() []int {
+	wg.stats.lock.RLock()
+	defer wg.stats.lock.RUnlock()
+
+	result := []int{}
+	for i, bits := range wg.stats.completed {
+		for j := 0; j < 64; j++ {
+			if bits&(1<<uint(j)) != 0 {
+				result = append(result, i*64+j)
+			}
+		}
+	}
+	return result
+}
+
+// GetTimings returns execution time of tasks of the current run in Add order
+// if SetTrackTimings is enabled
+func (wg *AdvancedWaitGroup) GetTimings() []TaskTiming {
+	wg.timingsLock.Lock()
+	defer wg.timingsLock.Unlock()
+
+	return append([]TaskTiming{}, wg.timings...)
+}
+
+func (wg *AdvancedWaitGroup) resetTimings() {
+	wg.timingsLock.Lock()
+	defer wg.timingsLock.Unlock()
+
+	wg.timings = nil
+	if wg.withTimings {
+		wg.timings = make([]TaskTiming, wg.length)
+		for i := range wg.timings {
+			wg.timings[i].TaskIndex = i
+		}
+	}
+}
+
+// recordTiming saves timing, timings grow for tasks added while waitgroup runs
+func (wg *AdvancedWaitGroup) recordTiming(timing TaskTiming) {
+	wg.timingsLock.Lock()
+	defer wg.timingsLock.Unlock()
+
+	for len(wg.timings) <= timing.TaskIndex {
+		wg.timings = append(wg.timings, TaskTiming{TaskIndex: len(wg.timings)})
+	}
+	wg.timings[timing.TaskIndex] = timing
+}
+
+func (wg *AdvancedWaitGroup) setStatus(status int) {
+	if status < StatusIdle || status > StatusCancelled {
+		return
+	}
+
+	wg.statusLock.Lock()
+	wg.status = status
+	wg.statusLock.Unlock()
+}
+
+// Status return result state string
+func (wg *AdvancedWaitGroup) Status() int {
+	wg.statusLock.RLock()
+	defer wg.statusLock.RUnlock()
+
+	return wg.status
+}
+
+// CheckStatus return result of status compare
+func (wg *AdvancedWaitGroup) CheckStatus(status int) bool {
+	if status < StatusIdle || status > StatusCancelled {
+		return false
+	}
+
+	wg.statusLock.RLock()
+	defer wg.statusLock.RUnlock()
+
+	return wg.status == status
+}
+
+func (s *waitGroupStats) reset(total int, startTime time.Time) {
+	s.lock.Lock()
+	s.total = total
+	s.success = 0
+	s.failed = 0
+	s.panicked = 0
+	s.reported = 0
+	s.leaked = 0
+	s.undeliv = 0
+	s.overwrote = 0
+	s.running = 0
+	s.startTime = startTime
+	s.finishTime = time.Time{}
+	s.stopped = false
+	s.completed = make([]uint64, (total+63)/64)
+	s.next = 0
+	s.held = map[int]error{}
+	s.stopCh = make(chan struct{})
+	s.lock.Unlock()
+}
+
+// stop marks that execution results are not collected anymore.
+// Tasks waiting to send results give up
+func (s *waitGroupStats) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped {
+		return
+	}
+	s.held = map[int]error{}
+	s.finishTime = time.Now()
+	s.stopped = true
+	close(s.stopCh)
+}
+
+// dropped returns the number of errors that were never collected
+func (s *waitGroupStats) dropped() int {
+	if !s.stopped || s.reported < s.failed {
+		return 0
+	}
+	return s.reported - s.failed
+}
+
+// addTask counts task added while waitgroup runs and returns its index
+func (s *waitGroupStats) addTask() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	index := s.total
+	s.total++
+	if len(s.completed)*64 < s.total {
+		s.completed = append(s.completed, 0)
+	}
+	return index
+}
+
+func (s *waitGroupStats) setOrdered(ordered bool) {
+	s.lock.Lock()
+	s.ordered = ordered
+	s.lock.Unlock()
+}
+
+// overwrite counts task dropped by ring buffer. Dropped task has no result,
+// but results held after it are released in InOrder mode
+func (s *waitGroupStats) overwrite(index int, failed chan<- error, done chan<- struct{}) {
+	s.lock.Lock()
+	s.overwrote++
+	ordered := s.ordered && !s.stopped
+	s.lock.Unlock()
+
+	if ordered {
+		go s.report(index, errOverwritten, failed, done)
+	}
+}
+
+func (s *waitGroupStats) inFlight() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.running
+}
+
+// undeliver counts error that didn't fit into ErrCh
+func (s *waitGroupStats) undeliver() {
+	s.lock.Lock()
+	s.undeliv++
+	s.lock.Unlock()
+}
+
+// leak counts running tasks as leaked
+func (s *waitGroupStats) leak() {
+	s.lock.Lock()
+	s.leaked = s.running
+	s.lock.Unlock()
+}
+
+func (s *waitGroupStats) startTask() {
+	s.lock.Lock()
+	s.running++
+	s.lock.Unlock()
+}
+
+// finishTask counts finished task and reports its result
+func (s *waitGroupStats) finishTask(index int, err error, failed chan<- error, done chan<- struct{}) {
+	s.lock.Lock()
+	s.running--
+	// Task that outlives its run may finish after Reset
+	if err == nil && !s.stopped && index/64 < len(s.completed) {
+		s.completed[index/64] |= 1 << uint(index%64)
+	}
+	s.lock.Unlock()
+
+	s.report(index, err, failed, done)
+}
+
+// skipTask reports task that didn't run as successful
+func (s *waitGroupStats) skipTask(index int, done chan<- struct{}) {
+	s.report(index, nil, nil, done)
+}
+
+// report sends task result, in index order if it's required by InOrder.
+// Results are sent outside of the stats lock, so the waitgroup loop
+// can't get stuck while a task waits for room in channels
+func (s *waitGroupStats) report(index int, err error, failed chan<- error, done chan<- struct{}) {
+	s.lock.RLock()
+	ordered := s.ordered
+	s.lock.RUnlock()
+	if ordered {
+		s.sendLock.Lock()
+		defer s.sendLock.Unlock()
+	}
+
+	s.lock.Lock()
+	if err != nil && err != errOverwritten {
+		s.reported++
+	}
+	stop := s.stopCh
+	if !s.ordered || s.stopped {
+		s.lock.Unlock()
+		if err != errOverwritten {
+			send(err, failed, done, stop)
+		}
+		return
+	}
+
+	s.held[index] = err
+	var ready []error
+	for {
+		err, ok := s.held[s.next]
+		if !ok {
+			break
+		}
+		delete(s.held, s.next)
+		s.next++
+		ready = append(ready, err)
+	}
+	s.lock.Unlock()
+
+	for _, err := range ready {
+		if err != errOverwritten {
+			send(err, failed, done, stop)
+		}
+	}
+}
+
+// send passes result to the waitgroup loop. Results that come after stop
+// are not collected, such errors are counted as dropped
+func send(err error, failed chan<- error, done chan<- struct{}, stop <-chan struct{}) {
+	if err == nil {
+		select {
+		case done <- struct{}{}:
+		case <-stop:
+		}
+		return
+	}
+
+	select {
+	case failed <- err:
+	case <-stop:
+	}
+}
+
+func (s *waitGroupStats) countSuccess() {
+	s.lock.Lock()
+	s.success++
+	s.lock.Unlock()
+}
+
+func (s *waitGroupStats) countFailed() {
+	s.lock.Lock()
+	s.failed++
+	s.lock.Unlock()
+}
+
+func (s *waitGroupStats) countPanic() {
+	s.lock.Lock()
+	s.panicked++
+	s.lock.Unlock()
 }
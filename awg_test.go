@@ -3,7 +3,12 @@ package awg
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -31,10 +36,38 @@ func errorFunc() error {
 	return errors.New("Test error")
 }
 
+func sleepFunc() error {
+	time.Sleep(100 * time.Millisecond)
+	return nil
+}
+
 func panicFunc() error {
 	panic("Test panic")
 }
 
+// concurrencyProbe records max number of its tasks running at once
+type concurrencyProbe struct {
+	lock    sync.Mutex
+	running int
+	max     int
+}
+
+func (p *concurrencyProbe) task() error {
+	p.lock.Lock()
+	p.running++
+	if p.running > p.max {
+		p.max = p.running
+	}
+	p.lock.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.lock.Lock()
+	p.running--
+	p.lock.Unlock()
+	return nil
+}
+
 // TestAdvancedWorkGroupTimeout test for timeout
 func Test_AdvancedWorkGroupTimeout(t *testing.T) {
 	var wg AdvancedWaitGroup
@@ -151,16 +184,16 @@ func Test_AdvancedWorkGroupSuccess_WithCapacity(t *testing.T) {
 	}
 }
 
-// Test_AdvancedWorkGroupCancel_Success test for cancel case
-func Test_AdvancedWorkGroupCancel_Success(t *testing.T) {
+// Test_AdvancedWorkGroupCancel test for cancel case
+func Test_AdvancedWorkGroupCancel(t *testing.T) {
 	var wg AdvancedWaitGroup
 
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
-	wg.Add(slowFunc)
-	wg.Add(slowFunc)
+	wg.Add(sleepFunc)
+	wg.Add(sleepFunc)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -170,25 +203,21 @@ func Test_AdvancedWorkGroupCancel_Success(t *testing.T) {
 		cancel()
 	}()
 
-	if errs := wg.WithContext(ctx).SetStopOnError(true).Start().GetAllErrors(); len(errs) != 0 {
-		t.Errorf("AWG result should be 'success'! But got errors %v", errs)
-	}
-
-	if wg.Status() != StatusSuccess {
-		t.Error("AWG result should be 'success'!")
+	if err := wg.WithContext(ctx).SetStopOnError(true).Start().GetLastError(); err != context.Canceled {
+		t.Errorf("AWG should stops with cancellation error! But got %v", err)
 	}
 }
 
-// Test_AdvancedWorkGroupCancelWithCapacity_Success test for cancel case with capacity
-func Test_AdvancedWorkGroupCancelWithCapacity_Success(t *testing.T) {
+// Test_AdvancedWorkGroupCancelWithCapacity test for cancel case with capacity
+func Test_AdvancedWorkGroupCancelWithCapacity(t *testing.T) {
 	var wg AdvancedWaitGroup
 
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
 	wg.Add(fastFunc)
-	wg.Add(slowFunc)
-	wg.Add(slowFunc)
+	wg.Add(sleepFunc)
+	wg.Add(sleepFunc)
 	wg.SetCapacity(2)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -199,12 +228,48 @@ func Test_AdvancedWorkGroupCancelWithCapacity_Success(t *testing.T) {
 		cancel()
 	}()
 
-	if errs := wg.WithContext(ctx).SetStopOnError(true).Start().GetAllErrors(); len(errs) != 0 {
-		t.Errorf("AWG result should be 'success'! But got errors %v", errs)
+	if err := wg.WithContext(ctx).SetStopOnError(true).Start().GetLastError(); err != context.Canceled {
+		t.Errorf("AWG should stops with cancellation error! But got %v", err)
 	}
+}
 
-	if wg.Status() != StatusSuccess {
-		t.Error("AWG result should be 'success'!")
+// Test_AdvancedWorkGroupCancellationError test for custom cancellation error
+func Test_AdvancedWorkGroupCancellationError(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(sleepFunc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errCancelled := errors.New("Test cancellation")
+	wg.WithContext(ctx).SetCancellationError(errCancelled).Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 1 || errs[0] != errCancelled {
+		t.Errorf("Should get custom cancellation error, got %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupCancelWithoutDeadline test for cancel case of context without deadline
+func Test_AdvancedWorkGroupCancelWithoutDeadline(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(fastFunc, sleepFunc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	wg.WithContext(ctx).Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 1 || errs[0] != context.Canceled {
+		t.Errorf("Should get cancellation error, got %v", errs)
+	}
+
+	if wg.Status() != StatusCancelled {
+		t.Errorf("AWG result should be 'cancelled', got %d", wg.Status())
 	}
 }
 
@@ -494,3 +559,2115 @@ func Test_AdvancedWorkGroupTimeout_Execution(t *testing.T) {
 	//Debug
 	t.Logf("Done %v of %v", count, maxProcs)
 }
+
+// Test_AdvancedWorkGroupGetStats test
+func Test_AdvancedWorkGroupGetStats(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if st := wg.GetStats(); st.Total != 0 || st.Duration != 0 || st.Status != StatusIdle {
+		t.Errorf("Idle wg should have empty stats, got %+v", st)
+	}
+
+	wg.AddSlice([]WaitgroupFunc{fastFunc, slowFunc, errorFunc, panicFunc})
+	st := wg.Start().GetStats()
+
+	if st.Total != 4 || st.Success != 2 || st.Error != 2 || st.Panic != 1 {
+		t.Errorf("Wrong counters %+v", st)
+	}
+
+	if st.Running != 0 || st.Dropped != 0 {
+		t.Errorf("Finished wg shouldn`t have running or dropped tasks, got %+v", st)
+	}
+
+	if st.Status != StatusSuccess || st.Duration <= 0 {
+		t.Errorf("Wrong status or duration %+v", st)
+	}
+}
+
+// Test_AdvancedWorkGroupMinimumGoroutines test for pool mode
+func Test_AdvancedWorkGroupMinimumGoroutines(t *testing.T) {
+	var wg AdvancedWaitGroup
+	var probe concurrencyProbe
+	task := probe.task
+
+	for i := 0; i < 10; i++ {
+		wg.Add(task)
+	}
+	wg.Add(errorFunc)
+
+	errs := wg.SetMinimumGoroutines(3).Start().GetAllErrors()
+	if len(errs) != 1 {
+		t.Errorf("Should get one error! Got %v", errs)
+	}
+
+	if probe.max > 3 {
+		t.Errorf("Pool of 3 workers runs %d tasks at once", probe.max)
+	}
+}
+
+type testContextKey string
+
+// Test_AdvancedWorkGroupSetContextValue test
+func Test_AdvancedWorkGroupSetContextValue(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.SetContextValue(testContextKey("first"), 1).
+		SetContextValue(testContextKey("second"), 2)
+
+	if wg.ctx.Value(testContextKey("first")) != 1 || wg.ctx.Value(testContextKey("second")) != 2 {
+		t.Error("Context should keep all values")
+	}
+
+	if errs := wg.MustAdd(fastFunc).Start().GetAllErrors(); len(errs) != 0 || wg.Status() != StatusSuccess {
+		t.Errorf("AWG result should be 'success'! But got errors %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg2 AdvancedWaitGroup
+	wg2.WithContext(ctx).SetContextValue(testContextKey("key"), "value")
+	cancel()
+
+	select {
+	case <-wg2.ctx.Done():
+	default:
+		t.Error("Value context should be derived from the group context")
+	}
+}
+
+// Test_WrapError test
+func Test_WrapError(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.AddSlice([]WaitgroupFunc{fastFunc, errorFunc, panicFunc})
+	wg.Start()
+
+	wrapped := errors.New("wrapped")
+	errs := WrapError(&wg, func(err error) error {
+		if err.Error() == "Test error" {
+			return wrapped
+		}
+		return nil
+	})
+
+	if len(errs) != 1 || errs[0] != wrapped {
+		t.Errorf("Should get one wrapped error, got %v", errs)
+	}
+
+	if wg.GetLastError() != wrapped || len(wg.GetAllErrors()) != 1 {
+		t.Error("Waitgroup should keep wrapped errors")
+	}
+}
+
+// Test_AdvancedWorkGroupDoneFn test for context propagation
+func Test_AdvancedWorkGroupDoneFn(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if testOnlyGetDoneFn(&wg) != nil {
+		t.Error("Done function shouldn`t be set before start")
+	}
+
+	wg.MustAdd(fastFunc).Start()
+	if testOnlyGetDoneFn(&wg)() != nil {
+		t.Error("Default done function should return nil channel")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg2 AdvancedWaitGroup
+	wg2.WithContext(ctx)
+	if testOnlyGetDoneFn(&wg2)() != ctx.Done() {
+		t.Error("Done function should be taken from context")
+	}
+}
+
+// Test_AdvancedWorkGroupAfterStart test
+func Test_AdvancedWorkGroupAfterStart(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	calls := 0
+	started := false
+	wg.Add(func() error {
+		if !started {
+			return errors.New("Task runs before hook")
+		}
+		return nil
+	})
+
+	wg.SetAfterStart(func() {
+		calls++
+		started = true
+	}).Start()
+
+	if calls != 1 {
+		t.Errorf("Hook should be called once, called %d times", calls)
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 0 {
+		t.Errorf("AWG result should be 'success'! But got errors %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupAfterStartPanic test
+func Test_AdvancedWorkGroupAfterStartPanic(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	run := false
+	wg.Add(func() error {
+		run = true
+		return nil
+	})
+
+	wg.SetAfterStart(func() {
+		panic("Test panic")
+	}).Start()
+
+	if wg.Status() != StatusError {
+		t.Error("AWG result should be 'error'!")
+	}
+
+	if run {
+		t.Error("Tasks shouldn`t run after hook panic")
+	}
+
+	if wg.GetLastError() == nil {
+		t.Error("Panic should be an error")
+	}
+}
+
+// Test_AdvancedWorkGroupGetContextError test
+func Test_AdvancedWorkGroupGetContextError(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if wg.MustAdd(fastFunc, errorFunc).Start().GetContextError() != nil {
+		t.Error("Shouldn`t get context error without context")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg2 AdvancedWaitGroup
+	wg2.Add(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	if err := wg2.WithContext(ctx).Start().GetContextError(); err != context.Canceled {
+		t.Errorf("Should get context error, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	var wg3 AdvancedWaitGroup
+	wg3.Add(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	if err := wg3.WithContext(ctx).Start().GetContextError(); err != context.DeadlineExceeded {
+		t.Errorf("Should get context error, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupGetContextErrorRunning test
+func Test_AdvancedWorkGroupGetContextErrorRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg AdvancedWaitGroup
+	wg.Add(sleepFunc)
+	wg.WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Start()
+		close(done)
+	}()
+
+	// Context error is read while Start stores it
+	for !wg.CheckStatus(StatusRunning) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	for wg.GetContextError() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	<-done
+
+	if err := wg.GetContextError(); err != context.Canceled {
+		t.Errorf("Should get context error, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupWithNilContext test
+func Test_AdvancedWorkGroupWithNilContext(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	defer func() {
+		if r := recover(); r != "awg: nil context passed to WithContext" {
+			t.Errorf("Should panic with descriptive message, got %v", r)
+		}
+	}()
+
+	wg.WithContext(nil)
+}
+
+// Test_AdvancedWorkGroupCapacity test
+func Test_AdvancedWorkGroupCapacity(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if wg.GetCapacity() != 0 {
+		t.Error("Default capacity should be 0")
+	}
+
+	if wg.SetCapacity(2).SetCapacity(-2).GetCapacity() != 2 {
+		t.Error("Negative capacity except CapacityUnbounded should be ignored")
+	}
+
+	if wg.SetCapacity(-1).GetCapacity() != CapacityUnbounded {
+		t.Error("Capacity -1 should mean unbounded")
+	}
+
+	if wg.SetCapacity(2).SetUnbounded().GetCapacity() != CapacityUnbounded {
+		t.Error("SetUnbounded should reset capacity")
+	}
+
+	wg.MustAdd(fastFunc, fastFunc, fastFunc).Start()
+	if cap(wg.receiver) != 3 {
+		t.Errorf("Unbounded channel should hold all tasks, got capacity %d", cap(wg.receiver))
+	}
+
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+}
+
+// Test_AdvancedWorkGroupAddAfterStart test
+func Test_AdvancedWorkGroupAddAfterStart(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if n, _, err := wg.Add(fastFunc); n != 1 || err != nil {
+		t.Errorf("Should add task in idle wg, got %d and %v", n, err)
+	}
+
+	wg.Start()
+	if n, _, err := wg.Add(fastFunc); n != 0 || err != ErrAlreadyStarted {
+		t.Errorf("Should get ErrAlreadyStarted, got %d and %v", n, err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrAlreadyStarted {
+			t.Errorf("MustAdd should panic with ErrAlreadyStarted, got %v", r)
+		}
+	}()
+	wg.MustAdd(fastFunc)
+}
+
+// Test_AdvancedWorkGroupAddDuringStart test
+func Test_AdvancedWorkGroupAddDuringStart(t *testing.T) {
+	var calls, added int32
+	task := func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+
+	var wg AdvancedWaitGroup
+	wg.Add(task)
+
+	// Each task is either run by Start or rejected by Add
+	var adders sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		adders.Add(1)
+		go func() {
+			defer adders.Done()
+			for j := 0; j < 100; j++ {
+				if n, _, err := wg.Add(task); err == nil {
+					atomic.AddInt32(&added, int32(n))
+				} else if err != ErrAlreadyStarted {
+					t.Errorf("Should get ErrAlreadyStarted, got %v", err)
+				}
+			}
+		}()
+	}
+	wg.Start()
+	adders.Wait()
+
+	if calls, added := atomic.LoadInt32(&calls), atomic.LoadInt32(&added); calls != added+1 {
+		t.Errorf("Added tasks should be called, called %d of %d", calls, added+1)
+	}
+}
+
+// Test_AdvancedWorkGroupGetAllErrorsCopy test
+func Test_AdvancedWorkGroupGetAllErrorsCopy(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.AddSlice([]WaitgroupFunc{fastFunc, errorFunc, errorFunc})
+	wg.Start()
+
+	errs := wg.GetAllErrors()
+	errs[0] = nil
+	_ = append(errs[:1], errors.New("Appended error"))
+
+	for _, err := range wg.GetAllErrors() {
+		if err == nil || err.Error() != "Test error" {
+			t.Errorf("Waitgroup errors are corrupted: %v", wg.GetAllErrors())
+		}
+	}
+}
+
+func dispatchOrder(seed int64, policy SchedulingPolicy, n int) []int {
+	var wg AdvancedWaitGroup
+
+	order := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(func() error {
+			order = append(order, i)
+			return nil
+		})
+	}
+
+	wg.SetMinimumGoroutines(1).SetSeed(seed).SetSchedulingPolicy(policy).Start()
+	return order
+}
+
+// Test_AdvancedWorkGroupSetSeed test
+func Test_AdvancedWorkGroupSetSeed(t *testing.T) {
+	for i, n := range dispatchOrder(0, PolicyFIFO, 10) {
+		if i != n {
+			t.Fatalf("Zero seed should keep the Add order, got %v", dispatchOrder(0, PolicyFIFO, 10))
+		}
+	}
+
+	first := dispatchOrder(42, PolicyFIFO, 10)
+	second := dispatchOrder(42, PolicyRandom, 10)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Same seed should give same order, got %v and %v", first, second)
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupSchedulingPolicy test
+func Test_AdvancedWorkGroupSchedulingPolicy(t *testing.T) {
+	order := dispatchOrder(0, PolicyLIFO, 10)
+	for i, n := range order {
+		if n != len(order)-1-i {
+			t.Fatalf("LIFO policy should reverse the Add order, got %v", order)
+		}
+	}
+
+	order = dispatchOrder(0, PolicyRandom, 10)
+	seen := map[int]bool{}
+	for _, n := range order {
+		seen[n] = true
+	}
+	if len(order) != 10 || len(seen) != 10 {
+		t.Errorf("Random policy should run every task once, got %v", order)
+	}
+}
+
+// Test_AdvancedWorkGroupSetDispatchOrder test
+func Test_AdvancedWorkGroupSetDispatchOrder(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var order []int
+	for i := 0; i < 5; i++ {
+		i := i
+		wg.Add(func() error {
+			order = append(order, i)
+			return nil
+		})
+	}
+
+	wg.SetMinimumGoroutines(1).SetDispatchOrder(true).Start()
+	if fmt.Sprint(order) != "[4 3 2 1 0]" {
+		t.Errorf("Reverse order should dispatch last added task first, got %v", order)
+	}
+
+	if wg.SetDispatchOrder(false).policy != PolicyFIFO {
+		t.Error("Forward order should restore FIFO policy")
+	}
+}
+
+// Test_InOrder test
+func Test_InOrder(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	for i := 0; i < 5; i++ {
+		i := i
+		wg.Add(func() error {
+			time.Sleep(time.Duration(5-i) * 10 * time.Millisecond)
+			return fmt.Errorf("Task %d", i)
+		})
+	}
+
+	start := time.Now()
+	InOrder(&wg).Start()
+
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("Tasks should run in parallel, took %v", d)
+	}
+
+	if fmt.Sprint(wg.GetAllErrors()) != "[Task 0 Task 1 Task 2 Task 3 Task 4]" {
+		t.Errorf("Results should be collected in Add order, got %v", wg.GetAllErrors())
+	}
+
+	wg.Reset()
+	wg.Add(sleepFunc, errorFunc, fastFunc)
+	InOrder(&wg).SetStopOnError(true).Start()
+
+	if st := wg.GetStats(); wg.Status() != StatusError || st.Success == 0 {
+		t.Errorf("Error should be processed after previous task, got %+v", st)
+	}
+}
+
+// Test_AdvancedWorkGroupSetDispatchPacing test
+func Test_AdvancedWorkGroupSetDispatchPacing(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	start := time.Now()
+	wg.Add(fastFunc, fastFunc, fastFunc, fastFunc)
+	wg.SetDispatchPacing(20 * time.Millisecond).Start()
+
+	if d := time.Since(start); wg.Status() != StatusSuccess || d < 60*time.Millisecond {
+		t.Errorf("Tasks should be dispatched with pacing, took %v", d)
+	}
+
+	wg.Reset()
+	start = time.Now()
+	wg.Add(fastFunc, fastFunc, fastFunc, fastFunc)
+	wg.SetDispatchPacing(time.Second).SetTimeout(50 * time.Millisecond).Start()
+
+	if d := time.Since(start); wg.Status() != StatusTimeout || d > 500*time.Millisecond {
+		t.Errorf("Pacing should stop by timeout, took %v", d)
+	}
+}
+
+// Test_AdvancedWorkGroupSetSorterNamed test
+func Test_AdvancedWorkGroupSetSorterNamed(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var order []string
+	named := func(name string) NamedTask {
+		return NamedTask{Name: name, F: func() error {
+			order = append(order, name)
+			return nil
+		}}
+	}
+
+	wg.AddNamed(named("c"), named("a"), named("b"))
+	wg.SetMinimumGoroutines(1).SetSchedulingPolicy(PolicyLIFO).SetSorterNamed(func(a, b NamedTask) bool {
+		return a.Name < b.Name
+	}).Start()
+
+	if strings.Join(order, "") != "abc" {
+		t.Errorf("Sorter should define dispatch order, got %v", order)
+	}
+}
+
+// Test_AdvancedWorkGroupSetPrioritizer test
+func Test_AdvancedWorkGroupSetPrioritizer(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var order []int
+	task := func(n int) WaitgroupFunc {
+		return func() error {
+			order = append(order, n)
+			return nil
+		}
+	}
+
+	wg.AddWithPriority(1, task(1))
+	wg.AddWithPriority(3, task(3))
+	wg.Add(task(0))
+	wg.AddNamed(NamedTask{Name: "two", Priority: 2, F: task(2)})
+	wg.SetMinimumGoroutines(1).SetPrioritizer(func(a, b NamedTask) int {
+		return b.Priority - a.Priority
+	}).Start()
+
+	if fmt.Sprint(order) != "[3 2 1 0]" {
+		t.Errorf("Tasks should run by priority, got %v", order)
+	}
+}
+
+// Test_AdvancedWorkGroupSetOnAbort test
+func Test_AdvancedWorkGroupSetOnAbort(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	calls := 0
+	remaining := 0
+	wg.SetMinimumGoroutines(1).SetTimeout(10 * time.Millisecond).SetOnAbort(func(n int) {
+		calls++
+		remaining = n
+	})
+	wg.Add(sleepFunc, sleepFunc, sleepFunc, sleepFunc, sleepFunc)
+	wg.Start()
+
+	if calls != 1 || remaining != 4 {
+		t.Errorf("Hook should be called once with 4 remaining tasks, got %d calls with %d", calls, remaining)
+	}
+
+	calls = 0
+	wg.Reset()
+	wg.SetOnAbort(func(n int) {
+		calls++
+	}).Add(fastFunc, fastFunc)
+	wg.Start()
+
+	if calls != 0 {
+		t.Error("Hook should not be called if all tasks are done")
+	}
+}
+
+// Test_AdvancedWorkGroupAddWithCleanup test
+func Test_AdvancedWorkGroupAddWithCleanup(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	cleaned := 0
+	withCleanup := func(f WaitgroupFunc) WaitgroupFuncWithCleanup {
+		return WaitgroupFuncWithCleanup{Run: f, Cleanup: func() {
+			lock.Lock()
+			defer lock.Unlock()
+			cleaned++
+		}}
+	}
+
+	wg.AddWithCleanup(withCleanup(errorFunc), withCleanup(fastFunc), withCleanup(fastFunc))
+	wg.AddWithCleanup(WaitgroupFuncWithCleanup{Run: fastFunc, Cleanup: func() {
+		panic("Test cleanup panic")
+	}})
+	wg.SetMinimumGoroutines(1).SetStopOnError(true).Start()
+
+	// Task taken by worker before stop may be still running
+	deadline := time.Now().Add(time.Second)
+	for {
+		lock.Lock()
+		n := cleaned
+		lock.Unlock()
+
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Cleanup should be called for each task, called %d times", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Test_AdvancedWorkGroupMaxWaitAfterDone test
+func Test_AdvancedWorkGroupMaxWaitAfterDone(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(func() error {
+		time.Sleep(30 * time.Millisecond)
+		return errorFunc()
+	}, func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	wg.SetTimeout(10 * time.Millisecond).MaxWaitAfterDone(100 * time.Millisecond).Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 2 {
+		t.Errorf("Should get timeout and task errors, got %v", errs)
+	}
+
+	if st := wg.GetStats(); st.Leaked != 1 || st.Error != 1 {
+		t.Errorf("Should get one failed and one leaked task, got %+v", st)
+	}
+
+	if wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by timeout!")
+	}
+}
+
+// Test_AdvancedWorkGroupSetMaxTaskDuration test
+func Test_AdvancedWorkGroupSetMaxTaskDuration(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	var violations []int
+	wg.SetMaxTaskDuration(50 * time.Millisecond).OnTaskSLAViolation(func(index int, d time.Duration) {
+		lock.Lock()
+		defer lock.Unlock()
+		violations = append(violations, index)
+	})
+	wg.Add(fastFunc, sleepFunc, fastFunc)
+	wg.Start()
+
+	if wg.GetExceededDurationCount() != 1 {
+		t.Errorf("Should count one slow task, got %d", wg.GetExceededDurationCount())
+	}
+
+	if len(violations) != 1 || violations[0] != 1 {
+		t.Errorf("Hook should get slow task index, got %v", violations)
+	}
+
+	if wg.Status() != StatusSuccess {
+		t.Error("Slow task should not break waitgroup")
+	}
+}
+
+// Test_AdvancedWorkGroupSetMaxTaskDurationAfterReset test
+func Test_AdvancedWorkGroupSetMaxTaskDurationAfterReset(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	violations := make(chan int, 1)
+	wg.SetMaxTaskDuration(10 * time.Millisecond).OnTaskSLAViolation(func(index int, d time.Duration) {
+		violations <- index
+	})
+	wg.Add(sleepFunc)
+	wg.SetTimeout(20 * time.Millisecond).Start()
+	wg.Reset()
+
+	select {
+	case <-violations:
+	case <-time.After(time.Second):
+		t.Error("Task outliving its run should keep settings of the run")
+	}
+}
+
+// Test_AdvancedWorkGroupSetHealthCheck test
+func Test_AdvancedWorkGroupSetHealthCheck(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	checks := 0
+	wg.SetHealthCheck(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		checks++
+		return checks < 3
+	}, 10*time.Millisecond)
+	wg.Add(sleepFunc, sleepFunc)
+	wg.Start()
+
+	if _, ok := wg.GetLastError().(ErrorHealthCheckFailed); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", wg.GetLastError())
+	}
+
+	if wg.Status() != StatusError {
+		t.Error("AWG result should be 'error'!")
+	}
+
+	wg.Reset()
+	wg.SetHealthCheck(func() bool {
+		return true
+	}, 10*time.Millisecond)
+	wg.Add(sleepFunc)
+
+	if wg.Start().Status() != StatusSuccess {
+		t.Errorf("Healthy AWG result should be 'success', got %v", wg.GetAllErrors())
+	}
+}
+
+// Test_AdvancedWorkGroupGetCompletedTasks test
+func Test_AdvancedWorkGroupGetCompletedTasks(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var want []int
+	for i := 0; i < 100; i++ {
+		switch {
+		case i == 70:
+			wg.Add(errorFunc)
+		case i%2 == 0:
+			wg.Add(fastFunc)
+			want = append(want, i)
+		default:
+			wg.Add(sleepFunc)
+		}
+	}
+	wg.SetTimeout(50 * time.Millisecond).Start()
+
+	if completed := wg.GetCompletedTasks(); fmt.Sprint(completed) != fmt.Sprint(want) {
+		t.Errorf("Should get indices of fast tasks, got %v", completed)
+	}
+}
+
+// Test_AdvancedWorkGroupWithValue test
+func Test_AdvancedWorkGroupWithValue(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.WithValue(testContextKey("requestID"), "42").SetContextValue(testContextKey("ctx"), 1)
+
+	ctx := wg.Context()
+	var got interface{}
+	wg.Add(func() error {
+		got = GroupFromContext(ctx).Value(testContextKey("requestID"))
+		return nil
+	})
+	wg.Start()
+
+	if got != "42" {
+		t.Errorf("Task should get group value, got %v", got)
+	}
+
+	if ctx.Value(testContextKey("ctx")) != 1 {
+		t.Error("Group context should keep context values")
+	}
+
+	if GroupFromContext(context.Background()) != nil {
+		t.Error("Context without group should give nil")
+	}
+
+	wg.Reset()
+	if wg.Value(testContextKey("requestID")) != nil {
+		t.Error("Reset should clear values")
+	}
+}
+
+// Test_AdvancedWorkGroupAddCtxGroup test
+func Test_AdvancedWorkGroupAddCtxGroup(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var got interface{}
+	wg.AddCtx(func(ctx context.Context) error {
+		got = GroupFromContext(ctx).Value(testContextKey("requestID"))
+		return nil
+	})
+	wg.WithValue(testContextKey("requestID"), "42").Start()
+
+	if got != "42" {
+		t.Errorf("Task added by AddCtx should get group from its context, got %v", got)
+	}
+}
+
+// Test_AdvancedWorkGroupSetChannel test
+func Test_AdvancedWorkGroupSetChannel(t *testing.T) {
+	for _, workers := range []int{0, 2} {
+		var wg AdvancedWaitGroup
+
+		tasks := make(chan WaitgroupFunc)
+		go func() {
+			defer close(tasks)
+			for i := 0; i < 10; i++ {
+				tasks <- fastFunc
+			}
+			tasks <- errorFunc
+		}()
+
+		wg.Add(fastFunc)
+		wg.SetMinimumGoroutines(workers).SetChannel(tasks).Start()
+
+		if errs := wg.GetAllErrors(); len(errs) != 1 {
+			t.Errorf("Should get error of external task, got %v", errs)
+		}
+
+		if st := wg.GetStats(); st.Total != 12 || st.Success != 11 {
+			t.Errorf("Should run added and external tasks, got %+v", st)
+		}
+
+		if wg.Status() != StatusSuccess {
+			t.Error("AWG result should be 'success'!")
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupSetChannelConcurrency test
+func Test_AdvancedWorkGroupSetChannelConcurrency(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var probe concurrencyProbe
+	tasks := make(chan WaitgroupFunc)
+	go func() {
+		defer close(tasks)
+		for i := 0; i < 10; i++ {
+			tasks <- probe.task
+		}
+	}()
+
+	wg.SetChannel(tasks).Start()
+
+	if probe.max < 5 {
+		t.Errorf("External tasks should run concurrently, got %d at once", probe.max)
+	}
+
+	if st := wg.GetStats(); st.Success != 10 || wg.Status() != StatusSuccess {
+		t.Errorf("Should run all external tasks, got %+v", st)
+	}
+}
+
+// Test_AdvancedWorkGroupAddCtx test
+func Test_AdvancedWorkGroupAddCtx(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var got interface{}
+	wg.AddCtx(func(ctx context.Context) error {
+		got = ctx.Value(testContextKey("key"))
+		return nil
+	})
+	wg.SetContextValue(testContextKey("key"), "value").Start()
+
+	if got != "value" {
+		t.Errorf("Task should get waitgroup context, got value %v", got)
+	}
+
+	var wg2 AdvancedWaitGroup
+	wg2.AddSliceCtx([]WaitgroupFuncCtx{func(ctx context.Context) error {
+		return ctx.Err()
+	}})
+
+	if err := wg2.Start().GetLastError(); err != nil || wg2.Status() != StatusSuccess {
+		t.Errorf("Task should get background context, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupSetContextPropagation test
+func Test_AdvancedWorkGroupSetContextPropagation(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	next := 0
+	wg.SetContextValue(testContextKey("group"), "value").SetContextPropagation(func(parent context.Context) context.Context {
+		lock.Lock()
+		defer lock.Unlock()
+		next++
+		return context.WithValue(parent, testContextKey("task"), next)
+	})
+
+	seen := make(chan interface{}, 3)
+	for i := 0; i < 3; i++ {
+		wg.AddCtx(func(ctx context.Context) error {
+			if ctx.Value(testContextKey("group")) != "value" {
+				return errors.New("Task context should be derived from group context")
+			}
+			seen <- ctx.Value(testContextKey("task"))
+			return nil
+		})
+	}
+
+	if errs := wg.Start().GetAllErrors(); len(errs) != 0 {
+		t.Fatalf("AWG result should be 'success'! But got errors %v", errs)
+	}
+
+	close(seen)
+	values := map[interface{}]bool{}
+	for v := range seen {
+		values[v] = true
+	}
+	if len(values) != 3 {
+		t.Errorf("Each task should get its own context, got %v", values)
+	}
+}
+
+// Test_AdvancedWorkGroupAddCtxCancel test
+func Test_AdvancedWorkGroupAddCtxCancel(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	release := make(chan struct{})
+	wg.AddCtx(func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		<-release
+		return ctx.Err()
+	})
+	wg.WithContext(ctx).Start()
+	close(release)
+
+	if err := <-stopped; err != context.Canceled {
+		t.Errorf("Task should see cancellation, got %v", err)
+	}
+
+	if wg.Status() != StatusCancelled {
+		t.Errorf("AWG result should be 'cancelled', got %d", wg.Status())
+	}
+}
+
+// Test_AdvancedWorkGroupSetMinTasks test
+func Test_AdvancedWorkGroupSetMinTasks(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if err := wg.SetMinTasks(1).Start().GetLastError(); err != ErrInsufficientTasks {
+		t.Errorf("Should get insufficient tasks error, got %v", err)
+	}
+
+	if wg.Status() != StatusError {
+		t.Error("AWG result should be 'error'!")
+	}
+
+	wg.Reset()
+	if wg.SetMinTasks(1).MustAdd(fastFunc).Start().Status() != StatusSuccess {
+		t.Errorf("AWG result should be 'success', got %v", wg.GetAllErrors())
+	}
+}
+
+// Test_AdvancedWorkGroupSetSamplingRate test
+func Test_AdvancedWorkGroupSetSamplingRate(t *testing.T) {
+	run := func() []int {
+		var wg AdvancedWaitGroup
+
+		var lock sync.Mutex
+		var runs []int
+		for i := 0; i < 1000; i++ {
+			i := i
+			wg.Add(func() error {
+				lock.Lock()
+				defer lock.Unlock()
+				runs = append(runs, i)
+				return nil
+			})
+		}
+
+		wg.SetSeed(42).SetSamplingRate(0.1).Start()
+		if st := wg.GetStats(); st.Success != 1000 || wg.Status() != StatusSuccess {
+			t.Errorf("Skipped tasks should be successful, got %+v", st)
+		}
+		sort.Ints(runs)
+		return runs
+	}
+
+	runs := run()
+	if len(runs) < 50 || len(runs) > 150 {
+		t.Errorf("About 10%% of tasks should run, got %d", len(runs))
+	}
+
+	if again := run(); fmt.Sprint(again) != fmt.Sprint(runs) {
+		t.Errorf("Sampling should run the same tasks with seed, got %v and %v", runs, again)
+	}
+}
+
+// Test_AdvancedWorkGroupAddFuture test
+func Test_AdvancedWorkGroupAddFuture(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	producer := func(n int, f WaitgroupFunc) <-chan WaitgroupFunc {
+		ch := make(chan WaitgroupFunc, 1)
+		go func() {
+			defer close(ch)
+			for i := 0; i < n; i++ {
+				ch <- f
+			}
+		}()
+		return ch
+	}
+
+	wg.Add(fastFunc)
+	wg.AddFuture(producer(5, fastFunc)).AddFuture(producer(3, errorFunc)).Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 3 {
+		t.Errorf("Should get errors of all producers tasks, got %v", errs)
+	}
+
+	if st := wg.GetStats(); st.Total != 9 || st.Success != 6 {
+		t.Errorf("Should run tasks of all producers, got %+v", st)
+	}
+}
+
+// Test_AdvancedWorkGroupAddFutureBuffer test
+func Test_AdvancedWorkGroupAddFutureBuffer(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	for i := 0; i < 2; i++ {
+		ch := make(chan WaitgroupFunc, 10)
+		for j := 0; j < 10; j++ {
+			ch <- fastFunc
+		}
+		close(ch)
+		wg.AddFuture(ch)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	if merged := wg.feed(stop); cap(merged) != 20 {
+		t.Errorf("Merged feed should buffer tasks of all feeds, got %d", cap(merged))
+	}
+}
+
+// Test_AdvancedWorkGroupSetMaxQueueDepth test
+func Test_AdvancedWorkGroupSetMaxQueueDepth(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.SetMaxQueueDepth(2).MustAdd(fastFunc)
+
+	if n, _, err := wg.Add(fastFunc, fastFunc); n != 0 || err != ErrQueueFull {
+		t.Errorf("Should get queue full error, got %d and %v", n, err)
+	}
+
+	if n, _, err := wg.AddSlice([]WaitgroupFunc{errorFunc}); n != 1 || err != nil {
+		t.Errorf("Task should fit into queue, got %d and %v", n, err)
+	}
+
+	if _, _, err := wg.AddNamed(NamedTask{F: fastFunc}); err != ErrQueueFull {
+		t.Errorf("Should get queue full error, got %v", err)
+	}
+
+	if errs := wg.Start().GetAllErrors(); len(errs) != 1 || wg.GetStats().Total != 2 {
+		t.Errorf("Only fitting tasks should run, got %+v", wg.GetStats())
+	}
+}
+
+// Test_AdvancedWorkGroupSetChannelStrategy test
+func Test_AdvancedWorkGroupSetChannelStrategy(t *testing.T) {
+	var wg AdvancedWaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(fastFunc)
+	}
+
+	wg.SetChannelStrategy(StrategyUnbuffered).Start()
+	if st := wg.GetStats(); st.Success != 10 || st.Overwritten != 0 || wg.Status() != StatusSuccess {
+		t.Errorf("Unbuffered strategy should run all tasks, got %+v", st)
+	}
+
+	wg.Reset()
+
+	for i := 0; i < 10; i++ {
+		wg.Add(fastFunc)
+	}
+	wg.SetChannelStrategy(StrategyRingBuffer).SetCapacity(1).SetMinimumGoroutines(1).Start()
+	if st := wg.GetStats(); st.Success != 10 || st.Overwritten != 0 || wg.Status() != StatusSuccess {
+		t.Errorf("Ring buffer strategy should not drop tasks added before start, got %+v", st)
+	}
+
+	wg.Reset()
+
+	var executed int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	tasks := make(chan WaitgroupFunc)
+	go func() {
+		defer close(tasks)
+		tasks <- func() error {
+			close(started)
+			<-release
+			atomic.AddInt64(&executed, 1)
+			return nil
+		}
+		<-started
+		for i := 0; i < 9; i++ {
+			tasks <- func() error {
+				atomic.AddInt64(&executed, 1)
+				return nil
+			}
+		}
+		time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	}()
+
+	wg.SetChannel(tasks)
+	wg.SetChannelStrategy(StrategyRingBuffer).SetCapacity(1).SetMinimumGoroutines(1).Start()
+	st := wg.GetStats()
+	if executed != 2 || st.Success != 2 || st.Overwritten != 8 || st.Total != 10 {
+		t.Errorf("Ring buffer strategy should keep only the newest waiting task, got %d executed and %+v", executed, st)
+	}
+
+	if wg.Status() != StatusSuccess {
+		t.Error("Dropped tasks should not break waitgroup")
+	}
+}
+
+// Test_AdvancedWorkGroupSetEarlyExitHook test
+func Test_AdvancedWorkGroupSetEarlyExitHook(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	calls := 0
+	status := StatusIdle
+	var errs []error
+	wg.SetStopOnError(true).SetEarlyExitHook(func(s int, e []error) {
+		calls++
+		status = s
+		errs = e
+	})
+	wg.Add(errorFunc, sleepFunc)
+	wg.Start()
+
+	if calls != 1 || status != StatusError || len(errs) != 1 {
+		t.Errorf("Hook should be called once with error, got %d calls with status %d and %v", calls, status, errs)
+	}
+
+	calls = 0
+	wg.Reset()
+	wg.SetEarlyExitHook(func(int, []error) {
+		calls++
+	}).Add(fastFunc, fastFunc)
+	wg.Start()
+
+	if calls != 0 {
+		t.Error("Hook should not be called if all tasks are done")
+	}
+}
+
+// Test_AdvancedWorkGroupGetTimings test
+func Test_AdvancedWorkGroupGetTimings(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(fastFunc, sleepFunc)
+	wg.Start()
+	if timings := wg.GetTimings(); len(timings) != 0 {
+		t.Errorf("Timings should not be tracked by default, got %v", timings)
+	}
+
+	wg.Reset()
+	wg.Add(fastFunc, errorFunc, sleepFunc, fastFunc)
+	wg.SetTrackTimings(true).SetTimeout(50 * time.Millisecond).SetMinimumGoroutines(1).Start()
+
+	timings := wg.GetTimings()
+	if len(timings) != 4 {
+		t.Fatalf("Should get timing per task, got %v", timings)
+	}
+
+	if timings[0].TaskIndex != 0 || timings[0].StartedAt.IsZero() || timings[0].FinishedAt.IsZero() {
+		t.Errorf("Wrong timing of fast task, got %+v", timings[0])
+	}
+
+	if timings[1].Err == nil || timings[1].FinishedAt.Sub(timings[1].StartedAt) != timings[1].Duration {
+		t.Errorf("Wrong timing of failed task, got %+v", timings[1])
+	}
+
+	if timings[3].TaskIndex != 3 || !timings[3].StartedAt.IsZero() {
+		t.Errorf("Task that never ran should have zero start time, got %+v", timings[3])
+	}
+}
+
+// Test_AdvancedWorkGroupGetTimingsAfterStop test
+func Test_AdvancedWorkGroupGetTimingsAfterStop(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(sleepFunc)
+	wg.SetTrackTimings(true).SetTimeout(10 * time.Millisecond).Start()
+	wg.SetTrackTimings(false)
+	time.Sleep(200 * time.Millisecond)
+
+	if timings := wg.GetTimings(); len(timings) != 1 || timings[0].FinishedAt.IsZero() {
+		t.Errorf("Task outliving its run should be tracked by settings of the run, got %v", timings)
+	}
+}
+
+// Test_AdvancedWorkGroupGetAbortedError test
+func Test_AdvancedWorkGroupGetAbortedError(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(sleepFunc, sleepFunc, sleepFunc, sleepFunc, sleepFunc)
+	wg.SetMinimumGoroutines(1).SetTimeout(10 * time.Millisecond).Start()
+
+	want := ErrGroupAborted{Reason: "timeout", TasksSkipped: 4}
+	if err := wg.GetAbortedError(); err != want {
+		t.Errorf("Should get %v, got %v", want, err)
+	}
+
+	if _, ok := wg.GetLastError().(ErrorTimeout); !ok {
+		t.Error("Aborted error should not replace timeout error")
+	}
+
+	wg.Reset()
+	wg.Add(fastFunc, errorFunc)
+	wg.Start()
+
+	if err := wg.GetAbortedError(); err != nil {
+		t.Errorf("Finished group should not be aborted, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupSetChannelBufferMultiplier test
+func Test_AdvancedWorkGroupSetChannelBufferMultiplier(t *testing.T) {
+	var wg AdvancedWaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(fastFunc)
+	}
+
+	for _, c := range []struct {
+		m   float64
+		cap int
+	}{
+		{m: 0.5, cap: 5},
+		{m: 0.01, cap: 1},
+		{m: 2, cap: 20},
+		{m: -1, cap: 10},
+	} {
+		wg.SetChannelBufferMultiplier(c.m).Restart()
+		if wg.Status() != StatusSuccess || cap(wg.receiver) != c.cap {
+			t.Errorf("Multiplier %v should make channel of %d tasks, got %d", c.m, c.cap, cap(wg.receiver))
+		}
+	}
+
+	wg.SetCapacity(3).Restart()
+	if cap(wg.receiver) != 3 {
+		t.Errorf("Capacity should take precedence over multiplier, got %d", cap(wg.receiver))
+	}
+
+	wg.SetChannelBufferMultiplier(0.1).SetUnbounded().Restart()
+	if cap(wg.receiver) != 10 {
+		t.Errorf("Unbounded capacity should take precedence over multiplier, got %d", cap(wg.receiver))
+	}
+}
+
+// Test_AdvancedWorkGroupSetMaxMemoryMB test
+func Test_AdvancedWorkGroupSetMaxMemoryMB(t *testing.T) {
+	defer func(d time.Duration) {
+		memoryCheckInterval = d
+	}(memoryCheckInterval)
+	memoryCheckInterval = 10 * time.Millisecond
+
+	var wg AdvancedWaitGroup
+
+	wg.Add(sleepFunc, sleepFunc)
+	wg.SetMaxMemoryMB(0.001).Start()
+
+	if wg.GetLastError() != ErrMemoryExceeded || wg.Status() != StatusError {
+		t.Errorf("AWG should stop by memory limit, got %v", wg.GetLastError())
+	}
+
+	wg.Reset()
+	wg.Add(sleepFunc, sleepFunc)
+	wg.SetMaxMemoryMB(1 << 20).Start()
+
+	if wg.Status() != StatusSuccess {
+		t.Errorf("AWG result should be 'success', got %v", wg.GetLastError())
+	}
+}
+
+// Test_AdvancedWorkGroupDo test
+func Test_AdvancedWorkGroupDo(t *testing.T) {
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, errorFunc, panicFunc)
+
+	if err := wg.Do(0); err != nil {
+		t.Errorf("Fast task should succeed, got %v", err)
+	}
+
+	if err := wg.Do(1); err == nil || err.Error() != "Test error" {
+		t.Errorf("Should get task error, got %v", err)
+	}
+
+	if _, ok := wg.Do(2).(ErrorPanic); !ok {
+		t.Errorf("Panic should be returned as error, got %v", wg.Do(2))
+	}
+
+	if err := wg.Do(3); err != ErrNoSuchTask {
+		t.Errorf("Should get ErrNoSuchTask, got %v", err)
+	}
+
+	if wg.Status() != StatusIdle || len(wg.GetAllErrors()) != 0 || wg.GetStats().Panic != 0 {
+		t.Errorf("Do should not affect waitgroup, got %+v", wg.GetStats())
+	}
+}
+
+// Test_AdvancedWorkGroupDoAfterStart test
+func Test_AdvancedWorkGroupDoAfterStart(t *testing.T) {
+	var wg AdvancedWaitGroup
+	wg.AddCtx(func(ctx context.Context) error {
+		return ctx.Err()
+	})
+
+	if err := wg.Start().GetLastError(); err != nil {
+		t.Errorf("Task should get live context in run, got %v", err)
+	}
+
+	if err := wg.Do(0); err != nil {
+		t.Errorf("Task should get live context after run, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupDoCleanup test
+func Test_AdvancedWorkGroupDoCleanup(t *testing.T) {
+	var cleaned []int
+
+	var wg AdvancedWaitGroup
+	wg.AddWithCleanup(
+		WaitgroupFuncWithCleanup{Run: fastFunc, Cleanup: func() { cleaned = append(cleaned, 0) }},
+		WaitgroupFuncWithCleanup{Run: panicFunc, Cleanup: func() { cleaned = append(cleaned, 1) }},
+	)
+	wg.Add(fastFunc)
+
+	wg.Do(0)
+	wg.Do(1)
+	if err := wg.Do(2); err != nil {
+		t.Errorf("Task without cleanup should succeed, got %v", err)
+	}
+
+	if len(cleaned) != 2 || cleaned[0] != 0 || cleaned[1] != 1 {
+		t.Errorf("Cleanup should be called after each task, got %v", cleaned)
+	}
+}
+
+// countingBreaker opens after limit failures
+type countingBreaker struct {
+	lock      sync.Mutex
+	failures  int
+	successes int
+	limit     int
+}
+
+func (b *countingBreaker) Allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.failures < b.limit
+}
+
+func (b *countingBreaker) ReportSuccess() {
+	b.lock.Lock()
+	b.successes++
+	b.lock.Unlock()
+}
+
+func (b *countingBreaker) ReportFailure() {
+	b.lock.Lock()
+	b.failures++
+	b.lock.Unlock()
+}
+
+// Test_AdvancedWorkGroupSetCircuitBreaker test
+func Test_AdvancedWorkGroupSetCircuitBreaker(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	cb := &countingBreaker{limit: 1}
+	wg.Add(fastFunc, errorFunc, fastFunc, fastFunc)
+	wg.SetMinimumGoroutines(1).SetCircuitBreaker(cb).Start()
+
+	if cb.successes != 1 || cb.failures != 1 {
+		t.Errorf("Results should be reported, got %d successes and %d failures", cb.successes, cb.failures)
+	}
+
+	errs := wg.GetAllErrors()
+	if len(errs) != 3 || errs[1] != ErrCircuitOpen || errs[2] != ErrCircuitOpen {
+		t.Errorf("Tasks after open circuit should fail with ErrCircuitOpen, got %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupSetCircuitBreakerAfterReset test
+func Test_AdvancedWorkGroupSetCircuitBreakerAfterReset(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	cb := &countingBreaker{limit: 1}
+	reported := make(chan struct{})
+	wg.Add(func() error {
+		defer close(reported)
+		return sleepFunc()
+	})
+	wg.SetCircuitBreaker(cb).SetTimeout(20 * time.Millisecond).Start()
+	wg.Reset()
+
+	<-reported
+	time.Sleep(10 * time.Millisecond)
+
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.successes != 1 {
+		t.Errorf("Task outliving its run should report to breaker of the run, got %d successes", cb.successes)
+	}
+}
+
+// Test_AdvancedWorkGroupSetStickyErrors test
+func Test_AdvancedWorkGroupSetStickyErrors(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.SetStickyErrors(true).Add(errorFunc, panicFunc)
+	wg.Start()
+	wg.Restart()
+	wg.Reset()
+	wg.Add(errorFunc)
+	wg.Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 5 {
+		t.Errorf("Errors should accumulate across runs, got %v", errs)
+	}
+
+	wg.ClearErrors()
+	if len(wg.GetAllErrors()) != 0 || wg.GetStats().Panic != 0 {
+		t.Errorf("Errors should be cleared, got %v", wg.GetAllErrors())
+	}
+
+	wg.SetStickyErrors(false).Reset()
+	wg.Add(errorFunc)
+	wg.Start()
+	wg.Reset()
+
+	if len(wg.GetAllErrors()) != 0 {
+		t.Error("Reset should clear errors by default")
+	}
+}
+
+// Test_AdvancedWorkGroupGetQueuedCount test
+func Test_AdvancedWorkGroupGetQueuedCount(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	queued, running := -1, -1
+	wg.Add(func() error {
+		time.Sleep(10 * time.Millisecond)
+		queued, running = wg.GetQueuedCount(), wg.GetRunningCount()
+		return nil
+	}, fastFunc, fastFunc)
+	wg.SetMinimumGoroutines(1).Start()
+
+	if queued != 2 || running != 1 {
+		t.Errorf("Should get 2 queued and 1 running task, got %d and %d", queued, running)
+	}
+
+	if wg.GetQueuedCount() != 0 || wg.GetRunningCount() != 0 {
+		t.Error("Finished group should have no queued and running tasks")
+	}
+}
+
+// Test_AdvancedWorkGroupGetQueuedCountConcurrent test
+func Test_AdvancedWorkGroupGetQueuedCountConcurrent(t *testing.T) {
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, fastFunc)
+
+	stop := make(chan struct{})
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				if n := wg.GetQueuedCount(); n < 0 || n > 4 {
+					t.Errorf("Wrong number of queued tasks %d", n)
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		wg.Restart()
+	}
+	close(stop)
+	<-polled
+}
+
+// Test_AdvancedWorkGroupSetOnTaskComplete test
+func Test_AdvancedWorkGroupSetOnTaskComplete(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var calls []string
+	hook := func(name string) func(int, interface{}, error) {
+		return func(index int, result interface{}, err error) {
+			calls = append(calls, fmt.Sprint(name, index, result, err))
+		}
+	}
+
+	wg.Add(fastFunc, errorFunc)
+	wg.SetMinimumGoroutines(1).SetOnTaskComplete(hook("a")).SetOnTaskComplete(hook("b")).Start()
+
+	want := "[a0 <nil> <nil> b0 <nil> <nil> a1 <nil> Test error b1 <nil> Test error]"
+	if fmt.Sprint(calls) != want {
+		t.Errorf("Hooks should be called in order, got %v", calls)
+	}
+}
+
+// Test_AdvancedWorkGroupSetCancelOnFirstSuccess test
+func Test_AdvancedWorkGroupSetCancelOnFirstSuccess(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	start := time.Now()
+	wg.Add(errorFunc, fastFunc, sleepFunc, sleepFunc)
+	wg.SetCancelOnFirstSuccess().Start()
+
+	if d := time.Since(start); wg.Status() != StatusSuccess || d > 50*time.Millisecond {
+		t.Errorf("AWG should stop successfully after first success, took %v", d)
+	}
+}
+
+// Test_AdvancedWorkGroupAddOnce test
+func Test_AdvancedWorkGroupAddOnce(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if !wg.AddOnce("a", fastFunc) || !wg.AddOnce("b", errorFunc) {
+		t.Error("Tasks with new keys should be added")
+	}
+
+	if wg.AddOnce("a", errorFunc) {
+		t.Error("Task with the same key should not be added")
+	}
+
+	if wg.Start().GetStats().Total != 2 || len(wg.GetAllErrors()) != 1 {
+		t.Errorf("Should run 2 tasks, got %+v", wg.GetStats())
+	}
+
+	if wg.AddOnce("c", fastFunc) {
+		t.Error("Task should not be added in started waitgroup")
+	}
+
+	wg.Reset()
+	if !wg.AddOnce("a", fastFunc) {
+		t.Error("Reset should forget keys")
+	}
+}
+
+// Test_AdvancedWorkGroupWorkerInitFn test
+func Test_AdvancedWorkGroupWorkerInitFn(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	ids := map[int]int{}
+	allStarted := make(chan struct{})
+
+	wg.SetMinimumGoroutines(3).SetWorkerInitFn(func(id int) {
+		lock.Lock()
+		defer lock.Unlock()
+		ids[id]++
+		if len(ids) == 3 {
+			close(allStarted)
+		}
+	})
+
+	wg.Add(func() error {
+		<-allStarted
+		return nil
+	})
+	wg.Start()
+
+	for id := 0; id < 3; id++ {
+		if ids[id] != 1 {
+			t.Errorf("Worker %d should be initialized once, got %v", id, ids)
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupWorkerExitFn test
+func Test_AdvancedWorkGroupWorkerExitFn(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	exited := make(chan error, 3)
+	wg.SetMinimumGoroutines(3).SetWorkerExitFn(func(id int, err error) {
+		exited <- err
+	})
+
+	wg.Add(fastFunc, errorFunc, panicFunc)
+	wg.Start()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-exited:
+			if err != nil {
+				t.Errorf("Worker should exit normally, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("All workers should exit after wg finished")
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupAddFunc test
+func Test_AdvancedWorkGroupAddFunc(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	calls := 0
+	fn := func() {
+		lock.Lock()
+		calls++
+		lock.Unlock()
+	}
+
+	wg.AddFunc(fn)
+	wg.AddFuncSlice([]func(){fn, fn})
+	wg.AddFunc(func() {
+		panic("Test panic")
+	})
+
+	if errs := wg.Start().GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get one panic error, got %v", errs)
+	}
+
+	if calls != 3 {
+		t.Errorf("All functions should be called, called %d", calls)
+	}
+
+	if _, _, err := wg.AddFunc(fn); err != ErrAlreadyStarted {
+		t.Errorf("Should get ErrAlreadyStarted, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupRestart test
+func Test_AdvancedWorkGroupRestart(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(sleepFunc, func() error {
+		return errors.New("Test error")
+	})
+
+	wg.SetStopOnError(true).Start()
+	if wg.Status() != StatusError {
+		t.Error("AWG result should be 'error'!")
+	}
+
+	wg.Restart()
+	if wg.Status() != StatusError || len(wg.GetAllErrors()) != 1 {
+		t.Errorf("Restarted wg should keep settings and tasks, got errors %v", wg.GetAllErrors())
+	}
+
+	wg.Reset()
+	wg.Add(fastFunc)
+	wg.Start()
+	wg.Restart()
+	if wg.Status() != StatusSuccess || len(wg.GetAllErrors()) != 0 {
+		t.Error("Restarted wg result should be 'success'!")
+	}
+}
+
+// Test_AdvancedWorkGroupRestartRunning test
+func Test_AdvancedWorkGroupRestartRunning(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	var wg AdvancedWaitGroup
+	wg.Add(func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wg.Start()
+		close(done)
+	}()
+	for !wg.CheckStatus(StatusRunning) {
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Restart()
+	if !wg.CheckStatus(StatusRunning) {
+		t.Errorf("Restart of running wg shouldn't change status, got %d", wg.Status())
+	}
+
+	close(release)
+	<-done
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("Restart of running wg shouldn't run tasks, called %d times", calls)
+	}
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+}
+
+// Test_NewLimitedGroup test
+func Test_NewLimitedGroup(t *testing.T) {
+	var probe concurrencyProbe
+	task := probe.task
+
+	wg := NewLimitedGroup(2, task, task, task, task, task, errorFunc)
+	if wg.Status() != StatusIdle {
+		t.Error("New group shouldn`t be started")
+	}
+
+	if errs := wg.Start().GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get one error! Got %v", errs)
+	}
+
+	if probe.max > 2 {
+		t.Errorf("Limited group runs %d tasks at once", probe.max)
+	}
+}
+
+// chanSemaphore is a minimal semaphore.Weighted implementation
+type chanSemaphore chan struct{}
+
+func (s chanSemaphore) Acquire(ctx context.Context, n int64) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s chanSemaphore) Release(n int64) {
+	<-s
+}
+
+// Test_AdvancedWorkGroupSetSemaphore test
+func Test_AdvancedWorkGroupSetSemaphore(t *testing.T) {
+	var probe concurrencyProbe
+	sem := make(chanSemaphore, 2)
+
+	var groups sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		var wg AdvancedWaitGroup
+		wg.MustAdd(probe.task, probe.task, probe.task).SetMaxConcurrency(3).SetSemaphore(sem)
+
+		groups.Add(1)
+		go func() {
+			defer groups.Done()
+			wg.Start()
+		}()
+	}
+	groups.Wait()
+
+	if probe.max > 2 {
+		t.Errorf("Groups with shared semaphore run %d tasks at once", probe.max)
+	}
+}
+
+// Test_AdvancedWorkGroupConcurrencyAutoScaling test
+func Test_AdvancedWorkGroupConcurrencyAutoScaling(t *testing.T) {
+	var wg AdvancedWaitGroup
+	var probe concurrencyProbe
+
+	for i := 0; i < 50; i++ {
+		wg.Add(probe.task)
+	}
+
+	wg.SetConcurrencyAutoScaling(1, 3, 0.5).Start()
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+
+	if probe.max > 3 {
+		t.Errorf("Auto scaled group runs %d tasks at once", probe.max)
+	}
+}
+
+// Test_AdvancedWorkGroupConcurrencyRampUp test
+func Test_AdvancedWorkGroupConcurrencyRampUp(t *testing.T) {
+	var wg AdvancedWaitGroup
+	var probe concurrencyProbe
+
+	for i := 0; i < 100; i++ {
+		wg.Add(probe.task)
+	}
+
+	wg.SetConcurrencyRampUp(1, 4, 50*time.Millisecond).Start()
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+
+	if probe.max != 4 {
+		t.Errorf("Group should ramp up to 4 tasks at once, got %d", probe.max)
+	}
+}
+
+// Test_AdvancedWorkGroupIdleTimeout test
+func Test_AdvancedWorkGroupIdleTimeout(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	hung := make(chan struct{})
+	defer close(hung)
+
+	wg.Add(fastFunc, sleepFunc, func() error {
+		<-hung
+		return nil
+	})
+
+	wg.SetIdleTimeout(150 * time.Millisecond).Start()
+	if wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by idle timeout!")
+	}
+
+	if _, ok := wg.GetLastError().(ErrorIdleTimeout); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", wg.GetLastError())
+	}
+
+	if st := wg.GetStats(); st.Success != 2 {
+		t.Errorf("Tasks finished before idle timeout should succeed, got %+v", st)
+	}
+}
+
+// Test_AdvancedWorkGroupWithDeadline test
+func Test_AdvancedWorkGroupWithDeadline(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(fastFunc, sleepFunc)
+	wg.WithDeadline(time.Now().Add(10 * time.Millisecond)).Start()
+
+	if wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by timeout!")
+	}
+
+	if _, ok := wg.GetLastError().(ErrorTimeout); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", wg.GetLastError())
+	}
+
+	if _, ok := wg.ctx.Deadline(); !ok {
+		t.Error("Group context should have deadline")
+	}
+
+	wg.Reset()
+	if wg.ctx != nil {
+		t.Error("Reset should drop deadline context")
+	}
+
+	wg.Add(fastFunc)
+	wg.WithDeadline(time.Now().Add(time.Millisecond)).
+		WithDeadline(time.Now().Add(time.Second)).
+		Start()
+
+	if errs := wg.GetAllErrors(); len(errs) != 0 || wg.Status() != StatusSuccess {
+		t.Errorf("AWG result should be 'success'! But got errors %v", errs)
+	}
+}
+
+// Test_Filter test
+func Test_Filter(t *testing.T) {
+	tasks := Filter([]WaitgroupFunc{errorFunc, fastFunc, errorFunc, fastFunc}, func(i int) bool {
+		return i%2 == 1
+	})
+
+	if len(tasks) != 2 {
+		t.Fatalf("Should get 2 tasks, got %d", len(tasks))
+	}
+
+	var wg AdvancedWaitGroup
+	if errs := wg.MustAdd(tasks...).Start().GetAllErrors(); len(errs) != 0 {
+		t.Errorf("Failing tasks should be filtered out, got errors %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupStatusRunning test
+func Test_AdvancedWorkGroupStatusRunning(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wg.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Start()
+		close(finished)
+	}()
+
+	<-started
+	if wg.Status() != StatusRunning {
+		t.Error("AWG should be running!")
+	}
+
+	close(release)
+	<-finished
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+}
+
+// Test_AdvancedWorkGroupMustStart test
+func Test_AdvancedWorkGroupMustStart(t *testing.T) {
+	mustPanic := func(msg string, f func()) {
+		defer func() {
+			if r := recover(); r != msg {
+				t.Errorf("Should panic with %q, got %v", msg, r)
+			}
+		}()
+		f()
+	}
+
+	var wg AdvancedWaitGroup
+	mustPanic("awg: no tasks to start", func() {
+		wg.MustStart()
+	})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wg.Add(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		wg.MustStart()
+		close(finished)
+	}()
+
+	<-started
+	mustPanic("awg: waitgroup is running already", func() {
+		wg.MustStart()
+	})
+
+	close(release)
+	<-finished
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+}
+
+// Test_AdvancedWorkGroupAddSliceWithIndex test
+func Test_AdvancedWorkGroupAddSliceWithIndex(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	var lock sync.Mutex
+	seen := map[int]bool{}
+	wg.AddSliceWithIndex([]WaitgroupFunc{fastFunc, errorFunc, fastFunc}, func(i int, f WaitgroupFunc) WaitgroupFunc {
+		return func() error {
+			lock.Lock()
+			seen[i] = true
+			lock.Unlock()
+			return f()
+		}
+	})
+
+	if errs := wg.Start().GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get one error! Got %v", errs)
+	}
+
+	if len(seen) != 3 {
+		t.Errorf("All wrapped tasks should run, got %v", seen)
+	}
+}
+
+// Test_AdvancedWorkGroupErrCh test
+func Test_AdvancedWorkGroupErrCh(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	if wg.ErrCh() != nil {
+		t.Error("Channel should be nil before start")
+	}
+
+	release := make(chan struct{})
+	wg.Add(errorFunc, fastFunc, func() error {
+		<-release
+		return errors.New("Test error")
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Start()
+		close(finished)
+	}()
+
+	for wg.Status() != StatusRunning {
+		runtime.Gosched()
+	}
+
+	var errs []error
+	for err := range wg.ErrCh() {
+		errs = append(errs, err)
+		if len(errs) == 1 {
+			close(release)
+		}
+	}
+
+	<-finished
+	if len(errs) != 2 || len(wg.GetAllErrors()) != 2 {
+		t.Errorf("Should get two errors from channel, got %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupErrChFeed test
+func Test_AdvancedWorkGroupErrChFeed(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	fail := func() error {
+		return errors.New("Test error")
+	}
+	feed := make(chan WaitgroupFunc, 5)
+	wg.Add(fail)
+	wg.AddFuture(feed)
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Start()
+		close(finished)
+	}()
+
+	for wg.Status() != StatusRunning {
+		runtime.Gosched()
+	}
+
+	var errs []error
+	for err := range wg.ErrCh() {
+		errs = append(errs, err)
+		// Next task is fed when error of previous one is received
+		if len(errs) < 6 {
+			feed <- fail
+		} else {
+			close(feed)
+		}
+	}
+
+	<-finished
+	if len(errs) != 6 || wg.GetStats().Undelivered != 0 {
+		t.Errorf("Should get errors of all tasks from channel, got %v", errs)
+	}
+}
+
+// Test_AdvancedWorkGroupErrChUndelivered test
+func Test_AdvancedWorkGroupErrChUndelivered(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	fail := func() error {
+		return errors.New("Test error")
+	}
+	feed := make(chan WaitgroupFunc, 5)
+	for i := 0; i < 5; i++ {
+		feed <- fail
+	}
+	close(feed)
+
+	wg.Add(fail)
+	wg.AddFuture(feed).Start()
+
+	var errs []error
+	for err := range wg.ErrCh() {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 2 || wg.GetStats().Undelivered != 4 {
+		t.Errorf("Errors over channel buffer should be undelivered, got %v and %+v", errs, wg.GetStats())
+	}
+}
+
+// Test_AdvancedWorkGroupErrChNoLeak test
+func Test_AdvancedWorkGroupErrChNoLeak(t *testing.T) {
+	numGoroutines := runtime.NumGoroutine()
+
+	fail := func() error {
+		return errors.New("Test error")
+	}
+	for i := 0; i < 50; i++ {
+		var wg AdvancedWaitGroup
+
+		feed := make(chan WaitgroupFunc, 3)
+		for j := 0; j < 3; j++ {
+			feed <- fail
+		}
+		close(feed)
+		wg.AddFuture(feed)
+
+		finished := make(chan struct{})
+		go func() {
+			wg.Start()
+			close(finished)
+		}()
+		for wg.ErrCh() == nil {
+			runtime.Gosched()
+		}
+
+		// Reader takes one error and stops
+		<-wg.ErrCh()
+		<-finished
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > numGoroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > numGoroutines {
+		t.Errorf("We leaked %d goroutine(s)", n-numGoroutines)
+	}
+}
+
+// Test_AdvancedWorkGroupStopOnFirstPanic test
+func Test_AdvancedWorkGroupStopOnFirstPanic(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(errorFunc, errorFunc, fastFunc)
+	wg.SetStopOnFirstPanic().Start()
+
+	if wg.Status() != StatusSuccess || len(wg.GetAllErrors()) != 2 {
+		t.Error("AWG shouldn`t stop on errors")
+	}
+
+	wg.Reset()
+	wg.Add(sleepFunc, panicFunc)
+	wg.SetStopOnFirstPanic().Start()
+
+	if wg.Status() != StatusError {
+		t.Error("AWG should stops by panic!")
+	}
+
+	if _, ok := wg.GetLastError().(ErrorPanic); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", wg.GetLastError())
+	}
+}
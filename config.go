@@ -0,0 +1,66 @@
+package awg
+
+import (
+	"time"
+)
+
+// GroupConfig is waitgroup configuration, e.g. read from config file
+type GroupConfig struct {
+	Capacity       int  `json:"capacity" yaml:"capacity"`
+	MaxConcurrency int  `json:"maxConcurrency" yaml:"maxConcurrency"`
+	TimeoutMS      int  `json:"timeoutMs" yaml:"timeoutMs"`
+	StopOnError    bool `json:"stopOnError" yaml:"stopOnError"`
+}
+
+// NewFromConfig makes waitgroup configured by cfg. Zero values keep defaults
+func NewFromConfig(cfg GroupConfig) *AdvancedWaitGroup {
+	var wg AdvancedWaitGroup
+
+	wg.SetCapacity(cfg.Capacity).SetMaxConcurrency(cfg.MaxConcurrency).SetStopOnError(cfg.StopOnError)
+	if cfg.TimeoutMS > 0 {
+		wg.SetTimeout(time.Duration(cfg.TimeoutMS) * time.Millisecond)
+	}
+	return &wg
+}
+
+// Config returns current waitgroup configuration
+func (wg *AdvancedWaitGroup) Config() GroupConfig {
+	cfg := GroupConfig{
+		Capacity:       wg.GetCapacity(),
+		MaxConcurrency: wg.concurrency,
+		StopOnError:    wg.stopOnError,
+	}
+	if wg.timeout != nil {
+		cfg.TimeoutMS = int(*wg.timeout / time.Millisecond)
+	}
+	return cfg
+}
+
+// Options is waitgroup configuration for embedding into application config
+// structs (Viper, envconfig etc.). Zero values keep defaults
+type Options struct {
+	Capacity       int     `mapstructure:"capacity" json:"capacity" yaml:"capacity"`
+	MaxConcurrency int     `mapstructure:"maxConcurrency" json:"maxConcurrency" yaml:"maxConcurrency"`
+	TimeoutSeconds float64 `mapstructure:"timeoutSeconds" json:"timeoutSeconds" yaml:"timeoutSeconds"`
+	StopOnError    bool    `mapstructure:"stopOnError" json:"stopOnError" yaml:"stopOnError"`
+	// RetryCount is the number of retries of failed task (see SetRetryPolicy)
+	RetryCount     int `mapstructure:"retryCount" json:"retryCount" yaml:"retryCount"`
+	RetryBackoffMS int `mapstructure:"retryBackoffMs" json:"retryBackoffMs" yaml:"retryBackoffMs"`
+}
+
+// NewFromOptions makes waitgroup configured by o
+func NewFromOptions(o Options) *AdvancedWaitGroup {
+	var wg AdvancedWaitGroup
+
+	wg.SetCapacity(o.Capacity).SetMaxConcurrency(o.MaxConcurrency).SetStopOnError(o.StopOnError)
+	if o.TimeoutSeconds > 0 {
+		wg.SetTimeout(time.Duration(o.TimeoutSeconds * float64(time.Second)))
+	}
+	if o.RetryCount > 0 {
+		wg.SetRetryPolicy(RetryPolicy{
+			Attempts: o.RetryCount + 1,
+			Backoff:  time.Duration(o.RetryBackoffMS) * time.Millisecond,
+		})
+	}
+	return &wg
+}
@@ -0,0 +1,66 @@
+package awg
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// Test_NewFromConfig test
+func Test_NewFromConfig(t *testing.T) {
+	var cfg GroupConfig
+	data := `{"capacity": 2, "maxConcurrency": 3, "timeoutMs": 50, "stopOnError": true}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	wg := NewFromConfig(cfg)
+	if wg.Config() != cfg {
+		t.Errorf("Config should be applied, got %+v", wg.Config())
+	}
+
+	wg.Add(sleepFunc)
+	wg.Start()
+
+	if _, ok := wg.GetLastError().(ErrorTimeout); !ok || wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by timeout from config!")
+	}
+}
+
+// Test_AdvancedWorkGroupConfig test
+func Test_AdvancedWorkGroupConfig(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.SetTimeout(2 * time.Second).SetCapacity(CapacityUnbounded)
+
+	cfg := GroupConfig{Capacity: CapacityUnbounded, TimeoutMS: 2000}
+	if wg.Config() != cfg {
+		t.Errorf("Wrong config, got %+v", wg.Config())
+	}
+}
+
+// Test_NewFromOptions test
+func Test_NewFromOptions(t *testing.T) {
+	var o Options
+	data := `{"timeoutSeconds": 0.05, "retryCount": 2, "retryBackoffMs": 1}`
+	if err := json.Unmarshal([]byte(data), &o); err != nil {
+		t.Fatal(err)
+	}
+
+	f, calls := failingFunc(2)
+	wg := NewFromOptions(o)
+	wg.Add(f)
+	wg.Start()
+
+	if wg.Status() != StatusSuccess || *calls != 3 {
+		t.Errorf("Task should be retried twice, got status %d and %d calls", wg.Status(), *calls)
+	}
+
+	wg = NewFromOptions(o)
+	wg.Add(sleepFunc)
+	wg.Start()
+
+	if _, ok := wg.GetLastError().(ErrorTimeout); !ok || wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by timeout from options!")
+	}
+}
@@ -0,0 +1,200 @@
+package awg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Retry makes task that runs f up to attempts times until it succeeds.
+// The task returns the last error of f
+func Retry(f WaitgroupFunc, attempts int) WaitgroupFunc {
+	return RetryWithBackoff(f, attempts, 0)
+}
+
+// RetryWithBackoff is like Retry but waits backoff between attempts
+func RetryWithBackoff(f WaitgroupFunc, attempts int, backoff time.Duration) WaitgroupFunc {
+	return RetryCtx(context.Background(), f, attempts, backoff)
+}
+
+// RetryCtx is like RetryWithBackoff but stops retrying when ctx is done
+func RetryCtx(ctx context.Context, f WaitgroupFunc, attempts int, backoff time.Duration) WaitgroupFunc {
+	return func() error {
+		err := f()
+		for i := 1; i < attempts && err != nil; i++ {
+			if !sleep(ctx, backoff) {
+				break
+			}
+			err = f()
+		}
+		return err
+	}
+}
+
+// Fallback makes task that runs primary and if it fails runs fallback
+// returning its result
+func Fallback(primary, fallback WaitgroupFunc) WaitgroupFunc {
+	return func() error {
+		if err := primary(); err == nil {
+			return nil
+		}
+		return fallback()
+	}
+}
+
+// Timeout makes task that returns ErrorTimeout if f does not finish within d.
+// f is left running in background in this case
+func Timeout(d time.Duration, f WaitgroupFunc) WaitgroupFunc {
+	return func() error {
+		result := make(chan error, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					result <- panicError(r)
+				}
+			}()
+			result <- f()
+		}()
+
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case err := <-result:
+			return err
+		case <-t.C:
+			return ErrorTimeout(d)
+		}
+	}
+}
+
+// Debounce makes task that runs f d after it is called, if it is not called
+// again in the meantime. Calls within d coalesce into one run of f: the last
+// call returns the result of f and the others return nil
+func Debounce(f WaitgroupFunc, d time.Duration) WaitgroupFunc {
+	var lock sync.Mutex
+	var timer *time.Timer
+	var pending chan error
+
+	return func() error {
+		result := make(chan error, 1)
+
+		lock.Lock()
+		if timer != nil && timer.Stop() {
+			// Previous call is superseded
+			pending <- nil
+		}
+		pending = result
+		timer = time.AfterFunc(d, func() {
+			defer func() {
+				if r := recover(); r != nil {
+					result <- panicError(r)
+				}
+			}()
+			result <- f()
+		})
+		lock.Unlock()
+
+		return <-result
+	}
+}
+
+// Tee makes copies tasks that run f (e.g. for AddSlice). Negative copies
+// means no tasks
+func Tee(f WaitgroupFunc, copies int) []WaitgroupFunc {
+	if copies < 0 {
+		copies = 0
+	}
+
+	result := make([]WaitgroupFunc, copies)
+	for i := range result {
+		result[i] = func() error {
+			return f()
+		}
+	}
+	return result
+}
+
+// Sequentially makes task that runs funcs one by one until the first error,
+// which is returned
+func Sequentially(funcs ...WaitgroupFunc) WaitgroupFunc {
+	return func() error {
+		for _, f := range funcs {
+			if err := f(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Concurrently makes task that runs funcs in inner waitgroup and returns
+// the first error caught by it. Inner waitgroup has no context, use
+// ConcurrentlyCtx or Concurrently method of outer waitgroup to bind it
+func Concurrently(funcs ...WaitgroupFunc) WaitgroupFunc {
+	return func() error {
+		return concurrently(nil, funcs)
+	}
+}
+
+// ConcurrentlyCtx is like Concurrently but inner waitgroup is bound to
+// context of outer one (see AddCtx)
+func ConcurrentlyCtx(funcs ...WaitgroupFunc) WaitgroupFuncCtx {
+	return func(ctx context.Context) error {
+		return concurrently(ctx, funcs)
+	}
+}
+
+// Concurrently is like package Concurrently but inner waitgroup is bound to
+// context of wg run, so it stops when wg is cancelled or its context is done
+func (wg *AdvancedWaitGroup) Concurrently(funcs ...WaitgroupFunc) WaitgroupFunc {
+	return func() error {
+		return concurrently(wg.runContext(), funcs)
+	}
+}
+
+// concurrently runs funcs in waitgroup bound to ctx (if any)
+func concurrently(ctx context.Context, funcs []WaitgroupFunc) error {
+	var wg AdvancedWaitGroup
+	if ctx != nil {
+		wg.WithContext(ctx)
+	}
+	wg.MustAdd(funcs...).Start()
+
+	if errs := wg.GetAllErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Wrap replaces tasks already added in wg by tasks wrapped in middlewares.
+// The first of mw is the outermost one
+func Wrap(wg *AdvancedWaitGroup, mw ...func(WaitgroupFunc) WaitgroupFunc) *AdvancedWaitGroup {
+	for i, f := range wg.stackBuffer {
+		for j := len(mw) - 1; j >= 0; j-- {
+			f = mw[j](f)
+		}
+		wg.stackBuffer[i] = f
+	}
+	return wg
+}
+
+// sleep waits d and returns false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
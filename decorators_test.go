@@ -0,0 +1,258 @@
+package awg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingFunc returns task that fails n times before success
+func failingFunc(n int) (WaitgroupFunc, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= n {
+			return errors.New("Test error")
+		}
+		return nil
+	}, &calls
+}
+
+// Test_Retry test
+func Test_Retry(t *testing.T) {
+	f, calls := failingFunc(2)
+	if err := Retry(f, 3)(); err != nil || *calls != 3 {
+		t.Errorf("Task should succeed on third attempt, got %v after %d calls", err, *calls)
+	}
+
+	f, calls = failingFunc(3)
+	if err := Retry(f, 3)(); err == nil || *calls != 3 {
+		t.Errorf("Task should fail after 3 attempts, got %v after %d calls", err, *calls)
+	}
+
+	f, calls = failingFunc(1)
+	if err := Retry(f, 0)(); err == nil || *calls != 1 {
+		t.Errorf("Task should run once, got %v after %d calls", err, *calls)
+	}
+}
+
+// Test_RetryWithBackoff test
+func Test_RetryWithBackoff(t *testing.T) {
+	f, calls := failingFunc(2)
+
+	startTime := time.Now()
+	if err := RetryWithBackoff(f, 3, 10*time.Millisecond)(); err != nil || *calls != 3 {
+		t.Errorf("Task should succeed on third attempt, got %v after %d calls", err, *calls)
+	}
+
+	if d := time.Since(startTime); d < 20*time.Millisecond {
+		t.Errorf("Retries should wait backoff, took %v", d)
+	}
+}
+
+// Test_RetryCtx test
+func Test_RetryCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	f, calls := failingFunc(2)
+	if err := RetryCtx(ctx, f, 3, time.Second)(); err == nil || *calls != 1 {
+		t.Errorf("Task shouldn`t be retried after cancel, got %v after %d calls", err, *calls)
+	}
+}
+
+// Test_Fallback test
+func Test_Fallback(t *testing.T) {
+	fallback, calls := failingFunc(0)
+	if err := Fallback(fastFunc, fallback)(); err != nil || *calls != 0 {
+		t.Errorf("Fallback shouldn`t run after primary success, got %v after %d calls", err, *calls)
+	}
+
+	if err := Fallback(errorFunc, fallback)(); err != nil || *calls != 1 {
+		t.Errorf("Fallback should run after primary fail, got %v after %d calls", err, *calls)
+	}
+
+	errFallback := errors.New("Fallback error")
+	if err := Fallback(errorFunc, func() error { return errFallback })(); err != errFallback {
+		t.Errorf("Should get fallback error, got %v", err)
+	}
+}
+
+// Test_Timeout test
+func Test_Timeout(t *testing.T) {
+	if err := Timeout(time.Second, fastFunc)(); err != nil {
+		t.Errorf("Fast task shouldn`t be timed out, got %v", err)
+	}
+
+	if err := Timeout(time.Second, errorFunc)(); err == nil || err.Error() != "Test error" {
+		t.Errorf("Should get task error, got %v", err)
+	}
+
+	if err := Timeout(time.Second, panicFunc)(); err == nil {
+		t.Error("Panic should be an error")
+	}
+
+	err := Timeout(time.Millisecond, sleepFunc)()
+	if _, ok := err.(ErrorTimeout); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", err)
+	}
+}
+
+// Test_Debounce test
+func Test_Debounce(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	f, calls := failingFunc(1)
+	debounced := Debounce(f, 50*time.Millisecond)
+
+	wg.Add(debounced, debounced, debounced)
+	if errs := wg.Start().GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get error of the only run, got %v", errs)
+	}
+
+	if *calls != 1 {
+		t.Errorf("Debounced task should run once, got %d", *calls)
+	}
+}
+
+// Test_Tee test
+func Test_Tee(t *testing.T) {
+	var probe concurrencyProbe
+
+	var wg AdvancedWaitGroup
+	wg.AddSlice(Tee(probe.task, 3))
+	wg.Start()
+
+	if st := wg.GetStats(); st.Success != 3 {
+		t.Errorf("Should run 3 copies, got %+v", st)
+	}
+
+	if probe.max < 2 {
+		t.Errorf("Copies should run in parallel, got %d at once", probe.max)
+	}
+
+	if len(Tee(fastFunc, -1)) != 0 {
+		t.Error("Negative copies should make no tasks")
+	}
+}
+
+// Test_Wrap test
+func Test_Wrap(t *testing.T) {
+	var lock sync.Mutex
+	var calls []string
+	record := func(name string) func(WaitgroupFunc) WaitgroupFunc {
+		return func(f WaitgroupFunc) WaitgroupFunc {
+			return func() error {
+				lock.Lock()
+				calls = append(calls, name)
+				lock.Unlock()
+				return f()
+			}
+		}
+	}
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, errorFunc)
+	Wrap(&wg, record("outer"), record("inner")).SetMinimumGoroutines(1).Start()
+
+	if len(calls) != 4 || calls[0] != "outer" || calls[1] != "inner" {
+		t.Errorf("Middlewares should wrap each task left to right, got %v", calls)
+	}
+
+	if len(wg.GetAllErrors()) != 1 {
+		t.Error("Wrapped tasks should keep results")
+	}
+}
+
+// Test_Sequentially test
+func Test_Sequentially(t *testing.T) {
+	var order []string
+	step := func(name string, err error) WaitgroupFunc {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	failure := errors.New("Test error")
+	if err := Sequentially(step("a", nil), step("b", failure), step("c", nil))(); err != failure {
+		t.Errorf("Should return error of b, got %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "a" || order[1] != "b" {
+		t.Errorf("Should stop after b, got %v", order)
+	}
+
+	if err := Sequentially()(); err != nil {
+		t.Errorf("Empty sequence should succeed, got %v", err)
+	}
+}
+
+// Test_Concurrently test
+func Test_Concurrently(t *testing.T) {
+	var probe concurrencyProbe
+
+	var wg AdvancedWaitGroup
+	wg.Add(Concurrently(probe.task, probe.task, probe.task), Concurrently(fastFunc, errorFunc))
+	wg.SetMinimumGoroutines(1).Start()
+
+	if probe.max < 2 {
+		t.Errorf("Inner tasks should run in parallel, got %d at once", probe.max)
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 1 || errs[0].Error() != "Test error" {
+		t.Errorf("Should get error of inner task, got %v", errs)
+	}
+
+	if err := Concurrently()(); err != nil {
+		t.Errorf("Empty group should succeed, got %v", err)
+	}
+}
+
+// Test_ConcurrentlyCtx test
+func Test_ConcurrentlyCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ConcurrentlyCtx(sleepFunc)(ctx); err == nil {
+		t.Error("Inner group should stop by outer context")
+	}
+}
+
+// Test_AdvancedWorkGroupConcurrently test
+func Test_AdvancedWorkGroupConcurrently(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	inner := wg.Concurrently(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	stopped := make(chan error, 1)
+	wg.Add(func() error {
+		err := inner()
+		stopped <- err
+		return err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if wg.WithContext(ctx).Start().Status() != StatusTimeout {
+		t.Errorf("Outer group should stop by deadline, got status %d", wg.Status())
+	}
+
+	select {
+	case err := <-stopped:
+		if err == nil {
+			t.Error("Inner group should fail by outer deadline")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("Inner group should stop by outer deadline")
+	}
+
+	var idle AdvancedWaitGroup
+	if err := idle.Concurrently(fastFunc)(); err != nil {
+		t.Errorf("Inner group out of a run should succeed, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package awg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group is the interface of golang.org/x/sync/errgroup.Group.
+// AdvancedWaitGroup implements it, so it can replace errgroup
+type Group interface {
+	Go(f func() error)
+	Wait() error
+}
+
+// errGroupAdapter runs tasks of waitgroup made by FromErrGroup
+type errGroupAdapter struct {
+	group  Group
+	lock   sync.Mutex
+	tasks  int
+	errors []error
+}
+
+// FromErrGroup makes waitgroup that runs tasks with eg. Tasks passed to Go
+// are sent to eg immediately, Start (or Wait) waits for eg with waitgroup
+// timeout and context and collects all tasks errors (not only the first one).
+// Cancel stops waiting, tasks are left to eg
+func FromErrGroup(eg Group) *AdvancedWaitGroup {
+	return &AdvancedWaitGroup{errGroup: &errGroupAdapter{group: eg}}
+}
+
+// Go adds new task in waitgroup like Add does.
+// Unlike errgroup it panics if waitgroup is started already
+// (except waitgroup made by FromErrGroup)
+func (wg *AdvancedWaitGroup) Go(f func() error) {
+	if wg.errGroup != nil {
+		wg.errGroup.goTask(f)
+		return
+	}
+	wg.MustAdd(f)
+}
+
+// Wait runs tasks and returns last error caught by execution process
+func (wg *AdvancedWaitGroup) Wait() error {
+	return wg.Start().GetLastError()
+}
+
+func (a *errGroupAdapter) goTask(f func() error) {
+	a.lock.Lock()
+	a.tasks++
+	a.lock.Unlock()
+
+	a.group.Go(func() error {
+		err := f()
+		if err != nil {
+			a.lock.Lock()
+			a.errors = append(a.errors, err)
+			a.lock.Unlock()
+		}
+		return err
+	})
+}
+
+// startErrGroup waits for errgroup the same way Start waits for tasks
+func (wg *AdvancedWaitGroup) startErrGroup() {
+	wg.errGroup.lock.Lock()
+	tasks := wg.errGroup.tasks
+	wg.errGroup.lock.Unlock()
+
+	wg.statusLock.Lock()
+	if wg.status == StatusSuccess || wg.status == StatusRunning {
+		wg.statusLock.Unlock()
+		return
+	}
+	wg.errFeed = newErrorFeed(tasks+1, &wg.stats)
+	wg.cancelCh = make(chan struct{})
+	wg.status = StatusRunning
+	wg.statusLock.Unlock()
+	defer wg.errFeed.close()
+
+	if wg.doneFn == nil {
+		wg.doneFn = defaultDone
+	}
+	wg.setContextError(nil)
+	startTime := time.Now()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- wg.errGroup.group.Wait()
+	}()
+
+	var timer <-chan time.Time
+	if wg.timeout != nil {
+		timer = time.After(*wg.timeout)
+	}
+
+	select {
+	case err := <-result:
+		wg.errGroup.lock.Lock()
+		errs := wg.errGroup.errors
+		wg.errGroup.lock.Unlock()
+
+		for _, e := range errs {
+			wg.addError(e)
+		}
+		if err != nil && len(errs) == 0 {
+			// error of errgroup itself
+			wg.addError(err)
+		}
+		if err != nil && wg.stopOnError {
+			wg.setStatus(StatusError)
+		}
+	case t := <-timer:
+		wg.addError(ErrorTimeout(t.Sub(startTime)))
+		wg.setStatus(StatusTimeout)
+	case <-wg.cancelCh:
+		// Tasks are left to errgroup, waitgroup doesn't wait for them
+		wg.addError(wg.cancellationError())
+		wg.setStatus(StatusCancelled)
+	case <-wg.cancelOn:
+		wg.addError(wg.cancellationError())
+		wg.setStatus(StatusCancelled)
+	case <-wg.doneFn():
+		ctxErr := wg.ctx.Err()
+		wg.setContextError(ctxErr)
+		if ctxErr == context.Canceled {
+			wg.addError(wg.cancellationError())
+			wg.setStatus(StatusCancelled)
+		} else {
+			wg.addError(ErrorTimeout(time.Since(startTime)))
+			wg.setStatus(StatusTimeout)
+		}
+	}
+
+	if wg.CheckStatus(StatusRunning) {
+		wg.setStatus(StatusSuccess)
+	}
+}
@@ -0,0 +1,110 @@
+package awg
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ Group = &AdvancedWaitGroup{}
+
+// Test_AdvancedWorkGroupAsGroup test
+func Test_AdvancedWorkGroupAsGroup(t *testing.T) {
+	var g Group = &AdvancedWaitGroup{}
+
+	g.Go(fastFunc)
+	g.Go(errorFunc)
+
+	if err := g.Wait(); err == nil || err.Error() != "Test error" {
+		t.Errorf("Should get task error, got %v", err)
+	}
+
+	g = &AdvancedWaitGroup{}
+	g.Go(fastFunc)
+	if err := g.Wait(); err != nil {
+		t.Errorf("Shouldn`t get errors, got %v", err)
+	}
+}
+
+// syncGroup is a minimal errgroup.Group implementation
+type syncGroup struct {
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+}
+
+func (g *syncGroup) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+			})
+		}
+	}()
+}
+
+func (g *syncGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+// Test_FromErrGroup test
+func Test_FromErrGroup(t *testing.T) {
+	wg := FromErrGroup(&syncGroup{})
+
+	wg.Go(fastFunc)
+	wg.Go(errorFunc)
+	wg.Go(errorFunc)
+
+	if err := wg.SetStopOnError(true).Wait(); err == nil {
+		t.Error("Should get task error")
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 2 {
+		t.Errorf("Should get all tasks errors, got %v", errs)
+	}
+
+	if wg.Status() != StatusError {
+		t.Error("AWG result should be 'error'!")
+	}
+}
+
+// Test_FromErrGroupTimeout test
+func Test_FromErrGroupTimeout(t *testing.T) {
+	wg := FromErrGroup(&syncGroup{})
+
+	wg.Go(sleepFunc)
+	err := wg.SetTimeout(time.Millisecond).Wait()
+
+	if _, ok := err.(ErrorTimeout); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", err)
+	}
+
+	if wg.Status() != StatusTimeout {
+		t.Error("AWG should stops by timeout!")
+	}
+}
+
+// Test_FromErrGroupErrCh test
+func Test_FromErrGroupErrCh(t *testing.T) {
+	wg := FromErrGroup(&syncGroup{})
+
+	for i := 0; i < 3; i++ {
+		wg.Go(func() error {
+			return errors.New("Test error")
+		})
+	}
+	wg.Start()
+
+	var errs []error
+	for err := range wg.ErrCh() {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 3 {
+		t.Errorf("Should get tasks errors from channel, got %v", errs)
+	}
+}
@@ -0,0 +1,174 @@
+package awg
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	errTimeoutMessage     = "Wait group timeout after %v"
+	errIdleTimeoutMessage = "Wait group idle timeout after %v"
+	errPanicMessage       = "Panic handeled\n%v\n%s"
+	errHealthCheckMessage = "Wait group health check failed"
+	errGroupMessage       = "Group %d: %v"
+	errAbortedMessage     = "Wait group aborted by %s, %d tasks skipped"
+	stackBufferSize       = 1000
+)
+
+// ErrAlreadyStarted error on adding tasks in started waitgroup
+var ErrAlreadyStarted = errors.New("Wait group is already started")
+
+// ErrQueueFull error on adding tasks over SetMaxQueueDepth limit
+var ErrQueueFull = errors.New("Wait group queue is full")
+
+// ErrInsufficientTasks error on start of waitgroup with less tasks than
+// SetMinTasks requires
+var ErrInsufficientTasks = errors.New("Wait group has not enough tasks")
+
+// ErrCircuitOpen error of task that is not allowed by circuit breaker
+var ErrCircuitOpen = errors.New("Wait group circuit is open")
+
+// ErrNoSuchTask error on Do with index out of tasks range
+var ErrNoSuchTask = errors.New("Wait group has no such task")
+
+// ErrMemoryExceeded error on allocated memory over SetMaxMemoryMB limit
+var ErrMemoryExceeded = errors.New("Wait group memory limit is exceeded")
+
+// errOverwritten marks held result of task dropped by ring buffer
+var errOverwritten = errors.New("Wait group task is overwritten")
+
+// ErrorTimeout error on timeout
+type ErrorTimeout time.Duration
+
+// Error implementation
+func (e ErrorTimeout) Error() string {
+	return fmt.Sprintf(errTimeoutMessage, time.Duration(e).String())
+}
+
+// ErrorIdleTimeout error on idle timeout (no task finished in time)
+type ErrorIdleTimeout time.Duration
+
+// Error implementation
+func (e ErrorIdleTimeout) Error() string {
+	return fmt.Sprintf(errIdleTimeoutMessage, time.Duration(e).String())
+}
+
+// ErrorHealthCheckFailed error on failed health check (see SetHealthCheck)
+type ErrorHealthCheckFailed struct{}
+
+// Error implementation
+func (e ErrorHealthCheckFailed) Error() string {
+	return errHealthCheckMessage
+}
+
+// GroupError is error of group merged by MergeErrors
+type GroupError struct {
+	// GroupIndex is the position of group in MergeErrors arguments
+	GroupIndex int
+	// Err is the error caught by group
+	Err error
+}
+
+// Error implementation
+func (e GroupError) Error() string {
+	return fmt.Sprintf(errGroupMessage, e.GroupIndex, e.Err)
+}
+
+// Unwrap returns the error caught by group
+func (e GroupError) Unwrap() error {
+	return e.Err
+}
+
+// ErrGroupAborted describes waitgroup stopped early (see GetAbortedError)
+type ErrGroupAborted struct {
+	// Reason is "error", "timeout" or "cancel"
+	Reason string
+	// TasksSkipped is the number of tasks that were queued and not dispatched
+	TasksSkipped int
+}
+
+// Error implementation
+func (e ErrGroupAborted) Error() string {
+	return fmt.Sprintf(errAbortedMessage, e.Reason, e.TasksSkipped)
+}
+
+func abortReason(status int) string {
+	switch status {
+	case StatusTimeout:
+		return "timeout"
+	case StatusCancelled:
+		return "cancel"
+	}
+	return "error"
+}
+
+// ErrorGroup is error combining errors of several groups (see LoadBalanced)
+type ErrorGroup []error
+
+// Error implementation
+func (e ErrorGroup) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns combined errors
+func (e ErrorGroup) Unwrap() []error {
+	return e
+}
+
+// FilterErrors returns errors of errs that satisfy pred
+func FilterErrors(errs []error, pred func(error) bool) []error {
+	result := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if pred(err) {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// MapErrors returns errors of errs transformed by fn. Nil results are skipped
+func MapErrors(errs []error, fn func(error) error) []error {
+	result := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err = fn(err); err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// HasError reports whether any error of errs matches target (see errors.Is)
+func HasError(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorPanic error on panic in task
+type ErrorPanic struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of panicked goroutine
+	Stack []byte
+}
+
+// Error implementation
+func (e ErrorPanic) Error() string {
+	return fmt.Sprintf(errPanicMessage, e.Value, e.Stack)
+}
+
+func panicError(r interface{}) error {
+	buf := make([]byte, stackBufferSize)
+	count := runtime.Stack(buf, false)
+	return ErrorPanic{Value: r, Stack: buf[:count]}
+}
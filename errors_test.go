@@ -0,0 +1,47 @@
+package awg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test_FilterErrors test
+func Test_FilterErrors(t *testing.T) {
+	errs := []error{errors.New("Test error"), ErrorTimeout(time.Second), errors.New("Test error")}
+
+	timeouts := FilterErrors(errs, func(err error) bool {
+		_, ok := err.(ErrorTimeout)
+		return ok
+	})
+
+	if len(timeouts) != 1 || timeouts[0] != errs[1] {
+		t.Errorf("Should get one timeout error, got %v", timeouts)
+	}
+}
+
+// Test_MapErrors test
+func Test_MapErrors(t *testing.T) {
+	errs := []error{fmt.Errorf("Wrapped: %w", ErrAlreadyStarted), errors.New("Test error")}
+
+	unwrapped := MapErrors(errs, errors.Unwrap)
+
+	if len(unwrapped) != 1 || unwrapped[0] != ErrAlreadyStarted {
+		t.Errorf("Should get one unwrapped error, got %v", unwrapped)
+	}
+}
+
+// Test_HasError test
+func Test_HasError(t *testing.T) {
+	errs := []error{errors.New("Test error"), fmt.Errorf("Wrapped: %w", ErrAlreadyStarted)}
+
+	if !HasError(errs, ErrAlreadyStarted) {
+		t.Error("Should find wrapped error")
+	}
+
+	if HasError(errs, context.Canceled) {
+		t.Error("Should not find missing error")
+	}
+}
@@ -0,0 +1,6 @@
+package awg
+
+// testOnlyGetDoneFn returns function that waitgroup uses to wait for Done
+func testOnlyGetDoneFn(wg *AdvancedWaitGroup) func() <-chan struct{} {
+	return wg.doneFn
+}
@@ -0,0 +1,318 @@
+package awg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WaitgroupFuncG func with result
+type WaitgroupFuncG[T any] func() (T, error)
+
+// AdvancedWaitGroupG enhanced wait group that collects results of successful tasks
+type AdvancedWaitGroupG[T any] struct {
+	AdvancedWaitGroup
+	results     []indexedResult[T]
+	positions   map[int]int
+	funcs       map[int]WaitgroupFuncG[T]
+	resultsLock sync.Mutex
+	ordered     bool
+	observed    bool
+	buffer      interface{}
+}
+
+type indexedResult[T any] struct {
+	index int
+	value T
+}
+
+// Add adds new task in waitgroup and returns number of added tasks.
+// It returns ErrAlreadyStarted if waitgroup is started (until Reset)
+func (wg *AdvancedWaitGroupG[T]) Add(f ...WaitgroupFuncG[T]) (int, *AdvancedWaitGroupG[T], error) {
+	if !wg.observed {
+		// Results are cleared on each run however it is started
+		wg.observers = append(wg.observers, wg)
+		wg.resultOf = wg.resultAt
+		wg.observed = true
+	}
+
+	if wg.funcs == nil {
+		wg.funcs = map[int]WaitgroupFuncG[T]{}
+	}
+
+	tasks := make([]WaitgroupFunc, len(f))
+	for i, fn := range f {
+		index, fn := len(wg.stackBuffer)+i, fn
+		wg.funcs[index] = fn
+		tasks[i] = func() error {
+			v, err := fn()
+			if err == nil {
+				wg.addResult(index, v)
+			}
+			return err
+		}
+	}
+
+	if _, _, err := wg.AdvancedWaitGroup.Add(tasks...); err != nil {
+		for i := range f {
+			delete(wg.funcs, len(wg.stackBuffer)+i)
+		}
+		return 0, wg, err
+	}
+	return len(f), wg, nil
+}
+
+// Do runs i-th task (in Add order) in current goroutine and returns its
+// result and error, panic is returned as ErrorPanic. Like Do of
+// AdvancedWaitGroup it doesn't affect waitgroup, result is not added
+// to GetResults
+func (wg *AdvancedWaitGroupG[T]) Do(i int) (v T, err error) {
+	fn, ok := wg.funcs[i]
+	if !ok {
+		// Task is added without result (e.g. by AddCtx)
+		return v, wg.AdvancedWaitGroup.Do(i)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+
+	return fn()
+}
+
+// MustAdd is like Add but panics on error
+func (wg *AdvancedWaitGroupG[T]) MustAdd(f ...WaitgroupFuncG[T]) *AdvancedWaitGroupG[T] {
+	if _, _, err := wg.Add(f...); err != nil {
+		panic(err)
+	}
+	return wg
+}
+
+// AddSlice adds new tasks in waitgroup and returns number of added tasks
+func (wg *AdvancedWaitGroupG[T]) AddSlice(s []WaitgroupFuncG[T]) (int, *AdvancedWaitGroupG[T], error) {
+	return wg.Add(s...)
+}
+
+// SetResultOrdering makes GetResults return results in Add order
+// instead of completion order (default)
+func (wg *AdvancedWaitGroupG[T]) SetResultOrdering(ordered bool) *AdvancedWaitGroupG[T] {
+	wg.ordered = ordered
+	return wg
+}
+
+// SetResultBuffer makes waitgroup preallocate results for cap(buf) tasks,
+// GetResults returns results in buf (so it overwrites results returned
+// before). buf must be []T, Start panics otherwise
+func (wg *AdvancedWaitGroupG[T]) SetResultBuffer(buf interface{}) *AdvancedWaitGroupG[T] {
+	wg.buffer = buf
+	return wg
+}
+
+// Start runs tasks in separate goroutines
+func (wg *AdvancedWaitGroupG[T]) Start() *AdvancedWaitGroupG[T] {
+	wg.checkBuffer()
+	wg.AdvancedWaitGroup.Start()
+	return wg
+}
+
+// checkBuffer panics if result buffer is not []T
+func (wg *AdvancedWaitGroupG[T]) checkBuffer() {
+	if _, ok := wg.buffer.([]T); wg.buffer != nil && !ok {
+		panic(fmt.Sprintf("awg: result buffer should be %T, got %T", []T(nil), wg.buffer))
+	}
+}
+
+// Restart runs the same tasks again with the same settings
+func (wg *AdvancedWaitGroupG[T]) Restart() *AdvancedWaitGroupG[T] {
+	wg.AdvancedWaitGroup.Restart()
+	return wg
+}
+
+// Reset performs cleanup task queue, results and reset state
+func (wg *AdvancedWaitGroupG[T]) Reset() {
+	wg.AdvancedWaitGroup.Reset()
+	wg.ordered = false
+	wg.buffer = nil
+
+	wg.resultsLock.Lock()
+	wg.results = nil
+	wg.positions = nil
+	wg.resultsLock.Unlock()
+	wg.funcs = nil
+}
+
+// GetResults returns results of successful tasks
+func (wg *AdvancedWaitGroupG[T]) GetResults() []T {
+	wg.resultsLock.Lock()
+	results := make([]indexedResult[T], len(wg.results))
+	copy(results, wg.results)
+	wg.resultsLock.Unlock()
+
+	if wg.ordered {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].index < results[j].index
+		})
+	}
+
+	values, ok := wg.buffer.([]T)
+	if !ok {
+		values = make([]T, 0, len(results))
+	}
+	values = values[:0]
+	for _, r := range results {
+		values = append(values, r.value)
+	}
+	return values
+}
+
+func (wg *AdvancedWaitGroupG[T]) addResult(index int, v T) {
+	wg.resultsLock.Lock()
+	if wg.positions == nil {
+		wg.positions = map[int]int{}
+	}
+	wg.positions[index] = len(wg.results)
+	wg.results = append(wg.results, indexedResult[T]{index: index, value: v})
+	wg.resultsLock.Unlock()
+}
+
+// resultAt returns result of task with index or zero value if it failed
+func (wg *AdvancedWaitGroupG[T]) resultAt(index int) interface{} {
+	wg.resultsLock.Lock()
+	defer wg.resultsLock.Unlock()
+
+	var v T
+	if i, ok := wg.positions[index]; ok {
+		v = wg.results[i].value
+	}
+	return v
+}
+
+func (wg *AdvancedWaitGroupG[T]) groupStarted() {
+	wg.resultsLock.Lock()
+	wg.results = nil
+	if buf, ok := wg.buffer.([]T); ok {
+		wg.results = make([]indexedResult[T], 0, cap(buf))
+	}
+	wg.positions = nil
+	wg.resultsLock.Unlock()
+}
+
+func (wg *AdvancedWaitGroupG[T]) groupFinished(int) {}
+
+func (wg *AdvancedWaitGroupG[T]) taskStarted(*runSettings, int) {}
+
+func (wg *AdvancedWaitGroupG[T]) taskFinished(*runSettings, int, time.Duration, error, bool) {}
+
+// Gather runs groups concurrently like All and returns results of all groups
+// in groups order. It returns ErrorGroup of all group errors wrapped in
+// GroupError or nil if there are no errors
+func Gather[T any](groups []*AdvancedWaitGroupG[T]) ([]T, error) {
+	untyped := make([]*AdvancedWaitGroup, len(groups))
+	for i, g := range groups {
+		g.checkBuffer()
+		untyped[i] = &g.AdvancedWaitGroup
+	}
+	All(untyped...)
+
+	var results []T
+	for _, g := range groups {
+		results = append(results, g.GetResults()...)
+	}
+
+	if errs := MergeErrors(untyped...); len(errs) > 0 {
+		return results, ErrorGroup(errs)
+	}
+	return results, nil
+}
+
+// Parallel runs fn for each item at once in waitgroup configured by opts.
+// Tasks get ctx. It returns the first error caught or nil if all tasks succeed
+func Parallel[T any](ctx context.Context, items []T, fn func(context.Context, T) error, opts ...Option) error {
+	tasks := make([]WaitgroupFuncCtx, len(items))
+	for i, item := range items {
+		item := item
+		tasks[i] = func(ctx context.Context) error {
+			return fn(ctx, item)
+		}
+	}
+
+	wg := New(opts...).WithContext(ctx)
+	if _, _, err := wg.AddSliceCtx(tasks); err != nil {
+		return err
+	}
+	if errs := wg.Start().GetAllErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// OfSlice runs f for each item at once in waitgroup configured by opts.
+// It returns ErrorGroup of all errors caught or nil if all tasks succeed
+func OfSlice[T any](items []T, f func(T) error, opts ...Option) error {
+	tasks := make([]WaitgroupFunc, len(items))
+	for i, item := range items {
+		item := item
+		tasks[i] = func() error {
+			return f(item)
+		}
+	}
+
+	wg := New(opts...)
+	if _, _, err := wg.AddSlice(tasks); err != nil {
+		return err
+	}
+	if errs := wg.Start().GetAllErrors(); len(errs) > 0 {
+		return ErrorGroup(errs)
+	}
+	return nil
+}
+
+// FirstN runs f for each item at once in waitgroup configured by opts and
+// returns as soon as n items succeed, other tasks are abandoned. It returns
+// at most n succeeded items in completion order. Errors of failed tasks are
+// returned as ErrorGroup only with WithErrors option. It returns no items
+// without running f if n is zero or negative
+func FirstN[T any](n int, items []T, f func(T) error, opts ...Option) ([]T, error) {
+	if n <= 0 {
+		return []T{}, nil
+	}
+
+	var lock sync.Mutex
+	var succeeded []T
+
+	tasks := make([]WaitgroupFunc, len(items))
+	for i, item := range items {
+		item := item
+		tasks[i] = func() error {
+			if err := f(item); err != nil {
+				return err
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			if len(succeeded) < n {
+				succeeded = append(succeeded, item)
+			}
+			return nil
+		}
+	}
+
+	wg := New(opts...)
+	if _, _, err := wg.SetCancelAfterSuccesses(n).AddSlice(tasks); err != nil {
+		return nil, err
+	}
+	wg.Start()
+
+	lock.Lock()
+	result := append([]T{}, succeeded...)
+	lock.Unlock()
+
+	if errs := wg.GetAllErrors(); wg.withErrors && len(errs) > 0 {
+		return result, ErrorGroup(errs)
+	}
+	return result, nil
+}
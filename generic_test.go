@@ -0,0 +1,289 @@
+package awg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// delayedResult returns task that returns v after v milliseconds
+func delayedResult(v int) WaitgroupFuncG[int] {
+	return func() (int, error) {
+		time.Sleep(time.Duration(v) * time.Millisecond)
+		return v, nil
+	}
+}
+
+// Test_AdvancedWorkGroupGResults test
+func Test_AdvancedWorkGroupGResults(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	wg.Add(delayedResult(30), delayedResult(20), delayedResult(10))
+	wg.Add(func() (int, error) {
+		return 0, errors.New("Test error")
+	})
+
+	results := wg.Start().GetResults()
+	if len(results) != 3 || results[0] != 10 || results[2] != 30 {
+		t.Errorf("Results should be in completion order, got %v", results)
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get one error, got %v", errs)
+	}
+
+	if results = wg.Restart().GetResults(); len(results) != 3 {
+		t.Errorf("Restart should collect results again, got %v", results)
+	}
+}
+
+// Test_AdvancedWorkGroupGResultOrdering test
+func Test_AdvancedWorkGroupGResultOrdering(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	wg.Add(delayedResult(30), delayedResult(20), delayedResult(10))
+	results := wg.SetResultOrdering(true).Start().GetResults()
+
+	if len(results) != 3 || results[0] != 30 || results[1] != 20 || results[2] != 10 {
+		t.Errorf("Results should be in Add order, got %v", results)
+	}
+
+	wg.Reset()
+	if results = wg.GetResults(); len(results) != 0 {
+		t.Errorf("Reset should clear results, got %v", results)
+	}
+}
+
+// Test_AdvancedWorkGroupGDo test
+func Test_AdvancedWorkGroupGDo(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	wg.Add(delayedResult(7))
+	wg.Start()
+
+	for i := 0; i < 2; i++ {
+		if v, err := wg.Do(0); v != 7 || err != nil {
+			t.Errorf("Should get task result, got %v and %v", v, err)
+		}
+	}
+
+	if results := wg.GetResults(); len(results) != 1 {
+		t.Errorf("Do should not add results, got %v", results)
+	}
+
+	if _, err := wg.Do(1); err != ErrNoSuchTask {
+		t.Errorf("Should get ErrNoSuchTask, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupGSetOnTaskComplete test
+func Test_AdvancedWorkGroupGSetOnTaskComplete(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	var lock sync.Mutex
+	results := map[int]interface{}{}
+	wg.MustAdd(delayedResult(10), func() (int, error) {
+		return 5, errors.New("Test error")
+	})
+	wg.SetOnTaskComplete(func(index int, result interface{}, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		results[index] = result
+	})
+	wg.Start()
+
+	if results[0] != 10 || results[1] != 0 {
+		t.Errorf("Hook should get typed result or zero value on error, got %v", results)
+	}
+}
+
+// Test_AdvancedWorkGroupGSetOnTaskCompleteAfterReset test
+func Test_AdvancedWorkGroupGSetOnTaskCompleteAfterReset(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	completed := make(chan int, 1)
+	wg.MustAdd(delayedResult(100))
+	wg.SetOnTaskComplete(func(index int, result interface{}, err error) {
+		completed <- index
+	})
+	wg.SetTimeout(10 * time.Millisecond).Start()
+	wg.Reset()
+
+	select {
+	case <-completed:
+	case <-time.After(time.Second):
+		t.Error("Task outliving its run should call hooks of the run")
+	}
+}
+
+// Test_AdvancedWorkGroupGSetResultBuffer test
+func Test_AdvancedWorkGroupGSetResultBuffer(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	buf := make([]int, 0, 100)
+	wg.MustAdd(delayedResult(10), delayedResult(20))
+	wg.SetResultBuffer(buf).Start()
+
+	if results := wg.GetResults(); len(results) != 2 || cap(wg.results) != 100 {
+		t.Errorf("Results should be collected in preallocated buffer, got %v", results)
+	}
+
+	if results := wg.GetResults(); &results[:1][0] != &buf[:1][0] {
+		t.Error("Results should be returned in buffer")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Should panic on buffer of wrong type")
+		}
+	}()
+
+	wg.Reset()
+	wg.SetResultBuffer([]string{}).Start()
+}
+
+// Test_Gather test
+func Test_Gather(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroupG[int]
+
+	wg1.SetResultOrdering(true).MustAdd(delayedResult(20), delayedResult(10))
+	wg2.MustAdd(delayedResult(5), func() (int, error) {
+		return 0, errors.New("Test error")
+	})
+
+	results, err := Gather([]*AdvancedWaitGroupG[int]{&wg1, &wg2})
+	if fmt.Sprint(results) != "[20 10 5]" {
+		t.Errorf("Results should be in groups order, got %v", results)
+	}
+
+	var errs ErrorGroup
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].(GroupError).GroupIndex != 1 {
+		t.Errorf("Should get error of second group, got %v", err)
+	}
+}
+
+// Test_GatherResultBuffer test
+func Test_GatherResultBuffer(t *testing.T) {
+	var wg AdvancedWaitGroupG[int]
+
+	started := false
+	wg.MustAdd(func() (int, error) {
+		started = true
+		return 0, nil
+	})
+	wg.SetResultBuffer([]string{})
+
+	defer func() {
+		if r := recover(); r == nil || started {
+			t.Error("Gather should panic on buffer of wrong type before start")
+		}
+	}()
+	Gather([]*AdvancedWaitGroupG[int]{&wg})
+}
+
+// Test_Parallel test
+func Test_Parallel(t *testing.T) {
+	var lock sync.Mutex
+	sum := 0
+	add := func(ctx context.Context, n int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		sum += n
+		return nil
+	}
+
+	if err := Parallel(context.Background(), []int{1, 2, 3}, add, Limit(2)); err != nil || sum != 6 {
+		t.Errorf("All items should be processed, got sum %d and error %v", sum, err)
+	}
+
+	err := Parallel(context.Background(), []int{1, 2, 3}, func(ctx context.Context, n int) error {
+		if n == 2 {
+			return errors.New("Test error")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "Test error" {
+		t.Errorf("Should get item error, got %v", err)
+	}
+
+	err = Parallel(context.Background(), []int{1}, func(ctx context.Context, n int) error {
+		return sleepFunc()
+	}, WithTimeout(10*time.Millisecond))
+	if _, ok := err.(ErrorTimeout); !ok {
+		t.Errorf("Wrong error type. Got %[1]T: %[1]q", err)
+	}
+}
+
+// Test_OfSlice test
+func Test_OfSlice(t *testing.T) {
+	var lock sync.Mutex
+	sum := 0
+	add := func(n int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		sum += n
+		return nil
+	}
+
+	if err := OfSlice([]int{1, 2, 3}, add, Limit(2)); err != nil || sum != 6 {
+		t.Errorf("All items should be processed, got sum %d and error %v", sum, err)
+	}
+
+	err := OfSlice([]int{1, 2, 3}, func(n int) error {
+		if n != 2 {
+			return fmt.Errorf("Test error %d", n)
+		}
+		return nil
+	})
+
+	var errs ErrorGroup
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("Should get ErrorGroup of item errors, got %v", err)
+	}
+}
+
+// Test_FirstN test
+func Test_FirstN(t *testing.T) {
+	try := func(n int) error {
+		if n%2 == 1 {
+			return fmt.Errorf("Test error %d", n)
+		}
+		time.Sleep(time.Duration(n) * 10 * time.Millisecond)
+		return nil
+	}
+
+	start := time.Now()
+	items, err := FirstN(2, []int{1, 2, 3, 4, 5, 6, 20}, try)
+	if err != nil || fmt.Sprint(items) != "[2 4]" {
+		t.Errorf("Should get first 2 succeeded items, got %v and %v", items, err)
+	}
+
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Errorf("Should return as soon as 2 items succeed, took %v", d)
+	}
+
+	items, err = FirstN(5, []int{1, 2, 3}, try, WithErrors())
+
+	var errs ErrorGroup
+	if len(items) != 1 || !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("Should get all succeeded items and errors, got %v and %v", items, err)
+	}
+
+	var calls int32
+	count := func(int) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+	for _, n := range []int{0, -1} {
+		if items, err := FirstN(n, []int{1, 2, 3}, count); len(items) != 0 || err != nil {
+			t.Errorf("Should get no items for n = %d, got %v and %v", n, items, err)
+		}
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 0 {
+		t.Errorf("Items shouldn't be tried for n <= 0, called %d", calls)
+	}
+}
@@ -0,0 +1,207 @@
+package awg
+
+import (
+	"sync"
+)
+
+// Chain runs groups one by one. Execution breaks on first group finished by
+// error, timeout or cancellation, the next groups are skipped. Returned group is already
+// finished, it keeps status of last executed group and errors of all executed
+// groups in order
+func Chain(groups ...*AdvancedWaitGroup) *AdvancedWaitGroup {
+	result := finishedGroup()
+
+	for _, g := range groups {
+		g.Start()
+		result.errors = append(result.errors, g.GetAllErrors()...)
+		result.setStatus(g.Status())
+
+		if g.CheckStatus(StatusError) || g.CheckStatus(StatusTimeout) || g.CheckStatus(StatusCancelled) {
+			break
+		}
+	}
+
+	return result
+}
+
+// Race runs groups concurrently and returns when first of them finishes
+// and the others are cancelled (see Cancel). Returned group keeps status
+// and errors of the winner. Groups that are not idle (running or finished
+// before) are skipped, so they can't win without running
+func Race(groups ...*AdvancedWaitGroup) *AdvancedWaitGroup {
+	result := finishedGroup()
+
+	idle := make([]*AdvancedWaitGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.CheckStatus(StatusIdle) {
+			idle = append(idle, g)
+		}
+	}
+	groups = idle
+	if len(groups) == 0 {
+		return result
+	}
+
+	// lost cancels groups that are not running yet when the winner finishes
+	lost := make(chan struct{})
+	finished := make(chan *AdvancedWaitGroup, len(groups))
+	for _, g := range groups {
+		g.cancelOn = lost
+		go func(g *AdvancedWaitGroup) {
+			g.Start()
+			g.cancelOn = nil
+			finished <- g
+		}(g)
+	}
+
+	winner := <-finished
+	close(lost)
+	for _, g := range groups {
+		if g != winner {
+			g.Cancel()
+		}
+	}
+	for i := 1; i < len(groups); i++ {
+		<-finished
+	}
+
+	result.errors = append(result.errors, winner.GetAllErrors()...)
+	result.setStatus(winner.Status())
+
+	return result
+}
+
+// All runs groups concurrently and waits for all of them. Returned group keeps
+// errors of all groups in order. Its status is StatusError if any group failed,
+// StatusTimeout if any group is timed out, StatusCancelled if any group is
+// cancelled and StatusSuccess otherwise
+func All(groups ...*AdvancedWaitGroup) *AdvancedWaitGroup {
+	result := finishedGroup()
+
+	var wg sync.WaitGroup
+	wg.Add(len(groups))
+	for _, g := range groups {
+		go func(g *AdvancedWaitGroup) {
+			defer wg.Done()
+			g.Start()
+		}(g)
+	}
+	wg.Wait()
+
+	for _, g := range groups {
+		result.errors = append(result.errors, g.GetAllErrors()...)
+
+		switch {
+		case g.CheckStatus(StatusError):
+			result.setStatus(StatusError)
+		case g.CheckStatus(StatusTimeout) && !result.CheckStatus(StatusError):
+			result.setStatus(StatusTimeout)
+		case g.CheckStatus(StatusCancelled) && result.CheckStatus(StatusSuccess):
+			result.setStatus(StatusCancelled)
+		}
+	}
+
+	return result
+}
+
+// DistributionPolicy picks index of group for the next task (see LoadBalanced)
+type DistributionPolicy func(groups []*AdvancedWaitGroup) int
+
+// RoundRobin makes policy that picks groups one by one
+func RoundRobin() DistributionPolicy {
+	next := 0
+	return func(groups []*AdvancedWaitGroup) int {
+		i := next % len(groups)
+		next++
+		return i
+	}
+}
+
+// LeastLoaded makes policy that picks group with the least number of tasks
+func LeastLoaded() DistributionPolicy {
+	return func(groups []*AdvancedWaitGroup) int {
+		least := 0
+		for i, g := range groups {
+			if len(g.stackBuffer) < len(groups[least].stackBuffer) {
+				least = i
+			}
+		}
+		return least
+	}
+}
+
+// LoadBalanced distributes tasks across groups by policy (RoundRobin by
+// default), then runs groups concurrently like All and waits for all of them.
+// It returns ErrorGroup of all group errors wrapped in GroupError or nil
+// if there are no errors. If any task can't be added, groups are not started
+// and tasks added by LoadBalanced are removed
+func LoadBalanced(groups []*AdvancedWaitGroup, tasks []WaitgroupFunc, policy ...DistributionPolicy) error {
+	if len(tasks) > 0 && len(groups) == 0 {
+		panic("awg: LoadBalanced is called without groups")
+	}
+
+	pick := RoundRobin()
+	if len(policy) > 0 {
+		pick = policy[0]
+	}
+
+	sizes := make([]int, len(groups))
+	for i, g := range groups {
+		sizes[i] = len(g.stackBuffer)
+	}
+
+	for _, f := range tasks {
+		i := pick(groups)
+		if _, _, err := groups[i].Add(f); err != nil {
+			for j, g := range groups {
+				g.stackBuffer = g.stackBuffer[:sizes[j]]
+				g.details = g.details[:sizes[j]]
+			}
+			return GroupError{GroupIndex: i, Err: err}
+		}
+	}
+
+	All(groups...)
+	if errs := MergeErrors(groups...); len(errs) > 0 {
+		return ErrorGroup(errs)
+	}
+	return nil
+}
+
+// statusSeverity orders statuses for MergeStatus, the worst is the last
+var statusSeverity = []int{StatusIdle, StatusSuccess, StatusRunning, StatusCancelled, StatusTimeout, StatusError}
+
+// MergeErrors returns errors of all groups in order,
+// each error is wrapped in GroupError
+func MergeErrors(groups ...*AdvancedWaitGroup) []error {
+	var result []error
+	for i, g := range groups {
+		for _, err := range g.GetAllErrors() {
+			result = append(result, GroupError{GroupIndex: i, Err: err})
+		}
+	}
+	return result
+}
+
+// MergeStatus returns the worst status of groups:
+// StatusError, StatusTimeout, StatusCancelled, StatusRunning, StatusSuccess
+// and StatusIdle in that order
+func MergeStatus(groups ...*AdvancedWaitGroup) int {
+	worst := 0
+	for _, g := range groups {
+		status := g.Status()
+		for i := worst; i < len(statusSeverity); i++ {
+			if statusSeverity[i] == status {
+				worst = i
+			}
+		}
+	}
+	return statusSeverity[worst]
+}
+
+// finishedGroup makes synthetic group that is already finished successfully
+func finishedGroup() *AdvancedWaitGroup {
+	wg := &AdvancedWaitGroup{}
+	wg.setStatus(StatusSuccess)
+	return wg
+}
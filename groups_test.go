@@ -0,0 +1,340 @@
+package awg
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test_ChainSuccess test
+func Test_ChainSuccess(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	wg1.Add(fastFunc, errorFunc)
+	wg2.Add(fastFunc, errorFunc)
+
+	wg := Chain(&wg1, &wg2)
+	if wg.Status() != StatusSuccess {
+		t.Error("Chain result should be 'success'!")
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 2 {
+		t.Errorf("Should get errors of both groups, got %v", errs)
+	}
+}
+
+// Test_ChainStopOnError test
+func Test_ChainStopOnError(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	wg1.MustAdd(errorFunc).SetStopOnError(true)
+	wg2.Add(fastFunc)
+
+	wg := Chain(&wg1, &wg2)
+	if wg.Status() != StatusError {
+		t.Error("Chain result should be 'error'!")
+	}
+
+	if len(wg.GetAllErrors()) != 1 {
+		t.Error("Should get one error!")
+	}
+
+	if wg2.Status() != StatusIdle {
+		t.Error("Group after failed one shouldn`t run")
+	}
+}
+
+// Test_ChainStopOnCancel test
+func Test_ChainStopOnCancel(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wg1.MustAdd(sleepFunc).WithContext(ctx)
+	wg2.Add(fastFunc)
+
+	if wg := Chain(&wg1, &wg2); wg.Status() != StatusCancelled {
+		t.Error("Chain result should be 'cancelled'!")
+	}
+
+	if wg2.Status() != StatusIdle {
+		t.Error("Group after cancelled one shouldn`t run")
+	}
+}
+
+// Test_ChainEmpty test
+func Test_ChainEmpty(t *testing.T) {
+	if wg := Chain(); wg.Status() != StatusSuccess || wg.GetLastError() != nil {
+		t.Error("Empty chain should be successful")
+	}
+}
+
+// Test_RaceFirstWins test
+func Test_RaceFirstWins(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	wg1.Add(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	wg2.MustAdd(errorFunc).SetStopOnError(true)
+
+	wg := Race(&wg1, &wg2)
+	if wg.Status() != StatusError {
+		t.Error("Race result should be taken from the fastest group!")
+	}
+
+	if len(wg.GetAllErrors()) != 1 {
+		t.Error("Should get one error!")
+	}
+}
+
+// Test_RaceCancelsLosers test
+func Test_RaceCancelsLosers(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	started := make(chan struct{})
+	observed := make(chan error, 1)
+	wg1.AddCtx(func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-ctx.Done():
+			observed <- ctx.Err()
+		case <-time.After(time.Second):
+			observed <- nil
+		}
+		return nil
+	})
+	wg2.Add(func() error {
+		<-started
+		return nil
+	})
+
+	if wg := Race(&wg1, &wg2); wg.Status() != StatusSuccess {
+		t.Error("Race result should be taken from the fastest group!")
+	}
+
+	if wg1.Status() != StatusCancelled {
+		t.Errorf("Loser should be cancelled, got status %d", wg1.Status())
+	}
+
+	select {
+	case err := <-observed:
+		if err != context.Canceled {
+			t.Errorf("Tasks of loser should observe cancellation, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Task of loser should run")
+	}
+}
+
+// Test_RaceCancelsErrGroup test
+func Test_RaceCancelsErrGroup(t *testing.T) {
+	var wg1 AdvancedWaitGroup
+	wg2 := FromErrGroup(&syncGroup{})
+
+	wg1.Add(fastFunc)
+	wg2.Go(func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	startTime := time.Now()
+	if wg := Race(&wg1, wg2); wg.Status() != StatusSuccess {
+		t.Error("Race result should be taken from the fastest group!")
+	}
+
+	if d := time.Since(startTime); d > 500*time.Millisecond {
+		t.Errorf("Race should not wait for errgroup loser, took %v", d)
+	}
+
+	if wg2.Status() != StatusCancelled {
+		t.Errorf("Loser should be cancelled, got status %d", wg2.Status())
+	}
+}
+
+// Test_RaceSkipsFinished test
+func Test_RaceSkipsFinished(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	wg1.Add(fastFunc)
+	wg1.Start()
+	wg2.Add(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return errors.New("Test error")
+	})
+	wg2.SetStopOnError(true)
+
+	wg := Race(&wg1, &wg2)
+	if wg.Status() != StatusError || len(wg.GetAllErrors()) != 1 {
+		t.Errorf("Finished group shouldn't win, got status %d", wg.Status())
+	}
+
+	if wg1.Status() != StatusSuccess {
+		t.Errorf("Finished group should be left as is, got status %d", wg1.Status())
+	}
+}
+
+// Test_RaceEmpty test
+func Test_RaceEmpty(t *testing.T) {
+	if wg := Race(); wg.Status() != StatusSuccess {
+		t.Error("Empty race should be successful")
+	}
+}
+
+// Test_AllSuccess test
+func Test_AllSuccess(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	// Groups run in parallel so tasks count calls on their own
+	var calls int32
+	succeed := func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+	fail := func() error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("Test error")
+	}
+
+	wg1.Add(succeed, fail)
+	wg2.Add(sleepFunc, succeed, fail)
+
+	wg := All(&wg1, &wg2)
+	if wg.Status() != StatusSuccess {
+		t.Error("All result should be 'success'!")
+	}
+
+	if errs := wg.GetAllErrors(); len(errs) != 2 {
+		t.Errorf("Should get errors of both groups, got %v", errs)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 4 {
+		t.Errorf("All tasks should be called, called %d", calls)
+	}
+}
+
+// Test_AllStatus test
+func Test_AllStatus(t *testing.T) {
+	var wg1, wg2, wg3 AdvancedWaitGroup
+
+	wg1.Add(fastFunc)
+	wg2.MustAdd(slowFunc).SetTimeout(time.Nanosecond)
+	wg3.MustAdd(errorFunc).SetStopOnError(true)
+
+	if wg := All(&wg1, &wg2); wg.Status() != StatusTimeout {
+		t.Error("All result should be 'timeout'!")
+	}
+
+	wg1.Reset()
+	wg2.Reset()
+	wg1.Add(fastFunc)
+	wg2.MustAdd(slowFunc).SetTimeout(time.Nanosecond)
+
+	if wg := All(&wg1, &wg2, &wg3); wg.Status() != StatusError {
+		t.Error("All result should be 'error'!")
+	}
+}
+
+// Test_MergeErrors test
+func Test_MergeErrors(t *testing.T) {
+	var wg1, wg2, wg3 AdvancedWaitGroup
+
+	wg1.MustAdd(errorFunc).Start()
+	wg2.MustAdd(fastFunc).Start()
+	wg3.MustAdd(errorFunc, errorFunc).Start()
+
+	errs := MergeErrors(&wg1, &wg2, &wg3)
+	if len(errs) != 3 {
+		t.Fatalf("Should get errors of all groups, got %v", errs)
+	}
+
+	for i, index := range []int{0, 2, 2} {
+		if err, ok := errs[i].(GroupError); !ok || err.GroupIndex != index || err.Err.Error() != "Test error" {
+			t.Errorf("Wrong group error %v", errs[i])
+		}
+	}
+}
+
+// Test_MergeStatus test
+func Test_MergeStatus(t *testing.T) {
+	var idle, success, failed AdvancedWaitGroup
+
+	success.MustAdd(fastFunc).Start()
+	failed.MustAdd(errorFunc).SetStopOnError(true).Start()
+
+	if status := MergeStatus(&idle, &success); status != StatusSuccess {
+		t.Errorf("Merged status should be 'success', got %d", status)
+	}
+
+	if status := MergeStatus(&failed, &idle, &success); status != StatusError {
+		t.Errorf("Merged status should be 'error', got %d", status)
+	}
+
+	if status := MergeStatus(); status != StatusIdle {
+		t.Errorf("Merged status of no groups should be 'idle', got %d", status)
+	}
+}
+
+// Test_LoadBalanced test
+func Test_LoadBalanced(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	tasks := []WaitgroupFunc{fastFunc, errorFunc, fastFunc, errorFunc, fastFunc}
+	err := LoadBalanced([]*AdvancedWaitGroup{&wg1, &wg2}, tasks)
+
+	var errs ErrorGroup
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Should get errors of tasks, got %v", err)
+	}
+
+	var groupErr GroupError
+	if !errors.As(errs[0], &groupErr) || groupErr.GroupIndex != 1 {
+		t.Errorf("Failed tasks should go to second group, got %v", errs)
+	}
+
+	if wg1.GetStats().Total != 3 || wg2.GetStats().Total != 2 {
+		t.Errorf("Tasks should be distributed round-robin, got %d and %d", wg1.GetStats().Total, wg2.GetStats().Total)
+	}
+}
+
+// Test_LoadBalancedQueueFull test
+func Test_LoadBalancedQueueFull(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+	wg1.Add(fastFunc)
+	wg2.SetMaxQueueDepth(1)
+
+	tasks := []WaitgroupFunc{fastFunc, fastFunc, fastFunc, fastFunc}
+	err := LoadBalanced([]*AdvancedWaitGroup{&wg1, &wg2}, tasks)
+
+	var groupErr GroupError
+	if !errors.As(err, &groupErr) || groupErr.GroupIndex != 1 || groupErr.Err != ErrQueueFull {
+		t.Errorf("Should get error of full group, got %v", err)
+	}
+
+	if len(wg1.stackBuffer) != 1 || len(wg2.stackBuffer) != 0 || len(wg1.details) != 1 {
+		t.Errorf("Groups should keep only their own tasks, got %d and %d", len(wg1.stackBuffer), len(wg2.stackBuffer))
+	}
+
+	if wg1.Status() != StatusIdle || wg2.Status() != StatusIdle {
+		t.Error("Groups should not be started")
+	}
+}
+
+// Test_LoadBalancedLeastLoaded test
+func Test_LoadBalancedLeastLoaded(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+	wg1.Add(fastFunc, fastFunc)
+
+	tasks := []WaitgroupFunc{fastFunc, fastFunc, fastFunc, fastFunc}
+	if err := LoadBalanced([]*AdvancedWaitGroup{&wg1, &wg2}, tasks, LeastLoaded()); err != nil {
+		t.Fatal(err)
+	}
+
+	if wg1.GetStats().Total != 3 || wg2.GetStats().Total != 3 {
+		t.Errorf("Tasks should go to least loaded group, got %d and %d", wg1.GetStats().Total, wg2.GetStats().Total)
+	}
+}
@@ -0,0 +1,45 @@
+package awg
+
+import (
+	"encoding/json"
+)
+
+// taskPlaceholder is used in JSON instead of task functions
+const taskPlaceholder = "[func]"
+
+// groupState is a snapshot of waitgroup marshaled by JSON
+type groupState struct {
+	Status      int      `json:"status"`
+	Tasks       []string `json:"tasks"`
+	Errors      []string `json:"errors"`
+	Duration    string   `json:"duration"`
+	Capacity    int      `json:"capacity"`
+	StopOnError bool     `json:"stopOnError"`
+	Timeout     string   `json:"timeout,omitempty"`
+}
+
+// JSON returns snapshot of waitgroup settings and state in JSON for debugging.
+// It can be called any time, also while waitgroup is running
+func (wg *AdvancedWaitGroup) JSON() ([]byte, error) {
+	st := wg.GetStats()
+	state := groupState{
+		Status:      st.Status,
+		Tasks:       make([]string, len(wg.stackBuffer)),
+		Errors:      []string{},
+		Duration:    st.Duration.String(),
+		Capacity:    wg.GetCapacity(),
+		StopOnError: wg.stopOnError,
+	}
+
+	for i := range state.Tasks {
+		state.Tasks[i] = taskPlaceholder
+	}
+	for _, err := range wg.GetAllErrors() {
+		state.Errors = append(state.Errors, err.Error())
+	}
+	if wg.timeout != nil {
+		state.Timeout = wg.timeout.String()
+	}
+
+	return json.Marshal(state)
+}
@@ -0,0 +1,37 @@
+package awg
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// Test_AdvancedWorkGroupJSON test
+func Test_AdvancedWorkGroupJSON(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Add(fastFunc, errorFunc)
+	wg.SetTimeout(time.Second).SetStopOnError(true).Start()
+
+	data, err := wg.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state map[string]interface{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	if state["status"] != float64(StatusError) || state["stopOnError"] != true || state["timeout"] != "1s" {
+		t.Errorf("Wrong waitgroup state: %s", data)
+	}
+
+	if tasks, ok := state["tasks"].([]interface{}); !ok || len(tasks) != 2 || tasks[0] != "[func]" {
+		t.Errorf("Tasks should be marshaled as placeholders: %s", data)
+	}
+
+	if errs, ok := state["errors"].([]interface{}); !ok || len(errs) != 1 || errs[0] != "Test error" {
+		t.Errorf("Errors should be marshaled as messages: %s", data)
+	}
+}
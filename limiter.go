@@ -0,0 +1,202 @@
+package awg
+
+import (
+	"sync"
+	"time"
+)
+
+const autoScalingInterval = 100 * time.Millisecond
+
+// limiter bounds the number of tasks running at once
+type limiter struct {
+	lock   sync.Mutex
+	limit  int
+	active int
+	wake   chan struct{}
+}
+
+func newLimiter(limit int) *limiter {
+	return &limiter{
+		limit: limit,
+		wake:  make(chan struct{}),
+	}
+}
+
+// acquire waits for a free slot. It returns false if stop is closed first
+func (l *limiter) acquire(stop <-chan struct{}) bool {
+	for {
+		l.lock.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.lock.Unlock()
+			return true
+		}
+		wake := l.wake
+		l.lock.Unlock()
+
+		select {
+		case <-wake:
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// release frees the slot and wakes up waiting tasks
+func (l *limiter) release() {
+	l.lock.Lock()
+	l.active--
+	close(l.wake)
+	l.wake = make(chan struct{})
+	l.lock.Unlock()
+}
+
+// setLimit changes the limit, tasks that run already are not affected
+func (l *limiter) setLimit(limit int) {
+	l.lock.Lock()
+	l.limit = limit
+	close(l.wake)
+	l.wake = make(chan struct{})
+	l.lock.Unlock()
+}
+
+func (l *limiter) usage() (active, limit int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.active, l.limit
+}
+
+// autoScaling adjusts limiter between min and max by observed utilization
+type autoScaling struct {
+	min    int
+	max    int
+	target float64
+}
+
+// run adjusts limiter periodically until stop is closed
+func (a *autoScaling) run(l *limiter, stop <-chan struct{}) {
+	ticker := time.NewTicker(autoScalingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.setLimit(a.next(l.usage()))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// next returns new limit: it grows if all slots are busy and shrinks if
+// utilization is less than target
+func (a *autoScaling) next(active, limit int) int {
+	switch {
+	case active >= limit && limit < a.max:
+		return limit + 1
+	case float64(active) < a.target*float64(limit) && limit > a.min:
+		return limit - 1
+	}
+	return limit
+}
+
+// rampUp grows limiter linearly from initial to max during duration
+type rampUp struct {
+	initial  int
+	max      int
+	duration time.Duration
+}
+
+// run grows limiter periodically until it reaches max or stop is closed
+func (r *rampUp) run(l *limiter, stop <-chan struct{}) {
+	interval := autoScalingInterval
+	if steps := r.max - r.initial; steps > 0 && r.duration/time.Duration(steps) < interval {
+		interval = r.duration / time.Duration(steps)
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+			limit := r.limit(time.Since(start))
+			l.setLimit(limit)
+			if limit >= r.max {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// limit returns the limit after elapsed time since start
+func (r *rampUp) limit(elapsed time.Duration) int {
+	if elapsed >= r.duration {
+		return r.max
+	}
+	return r.initial + int(int64(r.max-r.initial)*int64(elapsed)/int64(r.duration))
+}
+
+// throttle is token bucket that limits rate of tasks start
+type throttle struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+func newThrottle(rate float64, burst int) *throttle {
+	if burst < 1 {
+		burst = 1
+	}
+	return &throttle{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token. It returns false if stop is closed before token is ready
+func (t *throttle) wait(stop <-chan struct{}) bool {
+	d := t.reserve(time.Now())
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// reserve takes a token and returns the time until it is ready
+func (t *throttle) reserve(now time.Time) time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > float64(t.burst) {
+		t.tokens = float64(t.burst)
+	}
+	t.last = now
+
+	t.tokens--
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
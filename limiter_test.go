@@ -0,0 +1,100 @@
+package awg
+
+import (
+	"testing"
+	"time"
+)
+
+// Test_LimiterAcquire test
+func Test_LimiterAcquire(t *testing.T) {
+	l := newLimiter(1)
+	stop := make(chan struct{})
+
+	if !l.acquire(stop) {
+		t.Fatal("Should acquire free slot")
+	}
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- l.acquire(stop)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Shouldn`t acquire slot over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.setLimit(2)
+	if !<-acquired {
+		t.Error("Should acquire slot after limit grows")
+	}
+
+	go func() {
+		acquired <- l.acquire(stop)
+	}()
+	close(stop)
+
+	if <-acquired {
+		t.Error("Shouldn`t acquire slot after stop")
+	}
+}
+
+// Test_AutoScalingNext test
+func Test_AutoScalingNext(t *testing.T) {
+	a := autoScaling{min: 1, max: 3, target: 0.5}
+
+	cases := []struct {
+		active, limit, next int
+	}{
+		{active: 2, limit: 2, next: 3},
+		{active: 3, limit: 3, next: 3},
+		{active: 1, limit: 3, next: 2},
+		{active: 1, limit: 2, next: 2},
+		{active: 0, limit: 1, next: 1},
+	}
+
+	for _, c := range cases {
+		if next := a.next(c.active, c.limit); next != c.next {
+			t.Errorf("Limit %d with %d active tasks should become %d, got %d", c.limit, c.active, c.next, next)
+		}
+	}
+}
+
+// Test_RampUpLimit test
+func Test_RampUpLimit(t *testing.T) {
+	r := rampUp{initial: 1, max: 5, duration: time.Second}
+
+	cases := []struct {
+		elapsed time.Duration
+		limit   int
+	}{
+		{elapsed: 0, limit: 1},
+		{elapsed: 500 * time.Millisecond, limit: 3},
+		{elapsed: 999 * time.Millisecond, limit: 4},
+		{elapsed: time.Second, limit: 5},
+		{elapsed: time.Hour, limit: 5},
+	}
+
+	for _, c := range cases {
+		if limit := r.limit(c.elapsed); limit != c.limit {
+			t.Errorf("Limit after %v should be %d, got %d", c.elapsed, c.limit, limit)
+		}
+	}
+}
+
+// Test_ThrottleReserve test
+func Test_ThrottleReserve(t *testing.T) {
+	th := newThrottle(10, 2)
+	now := th.last
+
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if d := th.reserve(now); d != want {
+			t.Errorf("Token %d should be ready in %v, got %v", i, want, d)
+		}
+	}
+
+	if d := th.reserve(now.Add(time.Second)); d != 0 {
+		t.Errorf("Tokens should refill over time, got %v", d)
+	}
+}
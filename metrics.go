@@ -0,0 +1,93 @@
+//go:build prometheus
+
+package awg
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "awg"
+
+// prometheusObserver records tasks execution to prometheus metrics
+type prometheusObserver struct {
+	duration prometheus.Histogram
+	errors   prometheus.Counter
+	panics   prometheus.Counter
+	running  prometheus.Gauge
+}
+
+// RegisterMetrics makes waitgroup record tasks duration, errors, panics and
+// running goroutines to metrics registered in reg. Groups registered in the
+// same registry share the metrics
+func (wg *AdvancedWaitGroup) RegisterMetrics(reg prometheus.Registerer) error {
+	o := &prometheusObserver{
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "task_duration_seconds",
+			Help:      "Duration of waitgroup tasks execution.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "task_errors_total",
+			Help:      "Number of waitgroup tasks finished with error.",
+		}),
+		panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "task_panics_total",
+			Help:      "Number of waitgroup tasks finished with panic.",
+		}),
+		running: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "running_goroutines",
+			Help:      "Number of waitgroup tasks running right now.",
+		}),
+	}
+
+	var err error
+	if o.duration, err = registerCollector(reg, o.duration); err != nil {
+		return err
+	}
+	if o.errors, err = registerCollector(reg, o.errors); err != nil {
+		return err
+	}
+	if o.panics, err = registerCollector(reg, o.panics); err != nil {
+		return err
+	}
+	if o.running, err = registerCollector(reg, o.running); err != nil {
+		return err
+	}
+
+	wg.observers = append(wg.observers, o)
+	return nil
+}
+
+// registerCollector registers c or returns the same collector registered before
+func registerCollector[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+func (o *prometheusObserver) taskStarted(*runSettings, int) {
+	o.running.Inc()
+}
+
+func (o *prometheusObserver) taskFinished(_ *runSettings, _ int, d time.Duration, err error, panicked bool) {
+	o.running.Dec()
+	o.duration.Observe(d.Seconds())
+	if err != nil {
+		o.errors.Inc()
+	}
+	if panicked {
+		o.panics.Inc()
+	}
+}
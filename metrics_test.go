@@ -0,0 +1,42 @@
+//go:build prometheus
+
+package awg
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Test_RegisterMetrics test
+func Test_RegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	var wg1, wg2 AdvancedWaitGroup
+	if err := wg1.RegisterMetrics(reg); err != nil {
+		t.Fatal(err)
+	}
+	if err := wg2.RegisterMetrics(reg); err != nil {
+		t.Fatalf("Groups should share metrics, got %v", err)
+	}
+
+	wg1.Add(fastFunc, errorFunc, panicFunc)
+	wg1.Start()
+	wg2.Add(errorFunc)
+	wg2.Start()
+
+	o := wg1.observers[0].(*prometheusObserver)
+	if n := testutil.ToFloat64(o.errors); n != 3 {
+		t.Errorf("Should count 3 errors, got %v", n)
+	}
+	if n := testutil.ToFloat64(o.panics); n != 1 {
+		t.Errorf("Should count 1 panic, got %v", n)
+	}
+	if n := testutil.ToFloat64(o.running); n != 0 {
+		t.Errorf("Shouldn`t have running tasks, got %v", n)
+	}
+	if n := testutil.CollectAndCount(o.duration); n != 1 {
+		t.Errorf("Should collect duration histogram, got %v", n)
+	}
+}
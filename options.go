@@ -0,0 +1,68 @@
+package awg
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures waitgroup made by New
+type Option func(wg *AdvancedWaitGroup)
+
+// New makes waitgroup configured by opts
+func New(opts ...Option) *AdvancedWaitGroup {
+	var wg AdvancedWaitGroup
+	for _, opt := range opts {
+		opt(&wg)
+	}
+	return &wg
+}
+
+// NewWithContext makes waitgroup configured by opts and bound to ctx
+// (see WithContext)
+func NewWithContext(ctx context.Context, opts ...Option) *AdvancedWaitGroup {
+	return New(opts...).WithContext(ctx)
+}
+
+// NewRateLimited makes waitgroup with tasks started at rate of rps per
+// second with bursts up to burst tasks (see SetThrottleRate)
+func NewRateLimited(rps float64, burst int, tasks ...WaitgroupFunc) *AdvancedWaitGroup {
+	wg := New()
+	wg.SetThrottleRate(rps, burst).MustAdd(tasks...)
+	return wg
+}
+
+// Limit is option of SetMaxConcurrency
+func Limit(n int) Option {
+	return func(wg *AdvancedWaitGroup) {
+		wg.SetMaxConcurrency(n)
+	}
+}
+
+// WithTimeout is option of SetTimeout
+func WithTimeout(d time.Duration) Option {
+	return func(wg *AdvancedWaitGroup) {
+		wg.SetTimeout(d)
+	}
+}
+
+// StopOnError is option of SetStopOnError(true)
+func StopOnError() Option {
+	return func(wg *AdvancedWaitGroup) {
+		wg.SetStopOnError(true)
+	}
+}
+
+// WithRetry is option of SetRetryPolicy
+func WithRetry(policy RetryPolicy) Option {
+	return func(wg *AdvancedWaitGroup) {
+		wg.SetRetryPolicy(policy)
+	}
+}
+
+// WithErrors is option that makes helpers like FirstN return errors
+// of failed tasks
+func WithErrors() Option {
+	return func(wg *AdvancedWaitGroup) {
+		wg.withErrors = true
+	}
+}
@@ -0,0 +1,69 @@
+package awg
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Test_New test
+func Test_New(t *testing.T) {
+	var probe concurrencyProbe
+
+	wg := New(Limit(2), WithTimeout(time.Second), StopOnError())
+	wg.MustAdd(probe.task, probe.task, probe.task, probe.task)
+
+	if wg.Config() != (GroupConfig{MaxConcurrency: 2, TimeoutMS: 1000, StopOnError: true}) {
+		t.Errorf("Options should be applied, got %+v", wg.Config())
+	}
+
+	if wg.Start().Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+
+	if probe.max > 2 {
+		t.Errorf("Limited group runs %d tasks at once", probe.max)
+	}
+}
+
+// Test_NewWithRetry test
+func Test_NewWithRetry(t *testing.T) {
+	f, calls := failingFunc(1)
+
+	if err := New(WithRetry(RetryPolicy{Attempts: 2})).MustAdd(f).Start().GetLastError(); err != nil {
+		t.Errorf("Retried task should succeed, got %v", err)
+	}
+
+	if *calls != 2 {
+		t.Errorf("Task should run twice, got %d", *calls)
+	}
+}
+
+// Test_NewWithContext test
+func Test_NewWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wg := NewWithContext(ctx, StopOnError())
+	if wg.Config() != (GroupConfig{StopOnError: true}) {
+		t.Errorf("Options should be applied, got %+v", wg.Config())
+	}
+
+	if wg.MustAdd(sleepFunc).Start().Status() != StatusCancelled {
+		t.Errorf("AWG should be cancelled by context, got status %d", wg.Status())
+	}
+}
+
+// Test_NewRateLimited test
+func Test_NewRateLimited(t *testing.T) {
+	start := time.Now()
+	wg := NewRateLimited(50, 2, fastFunc, fastFunc, fastFunc, fastFunc, fastFunc, fastFunc).Start()
+
+	if wg.Status() != StatusSuccess || wg.GetStats().Success != 6 {
+		t.Errorf("All tasks should succeed, got %+v", wg.GetStats())
+	}
+
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("4 tasks over burst should take 80ms at 50 rps, got %v", d)
+	}
+}
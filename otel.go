@@ -0,0 +1,168 @@
+//go:build otel
+
+package awg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetOpenTelemetryTracer makes waitgroup start a span for each run and a child
+// span for each task. Task span is named by AddNamed or awg.task.N (N is Add
+// position) and it is in context of tasks added by AddCtx. Failed tasks get
+// error span status. The next call replaces the tracer, Reset removes it
+func (wg *AdvancedWaitGroup) SetOpenTelemetryTracer(tracer trace.Tracer) *AdvancedWaitGroup {
+	wg.setObserver(&otelObserver{wg: wg, tracer: tracer})
+	return wg
+}
+
+// otelObserver records waitgroup run and its tasks as spans
+type otelObserver struct {
+	wg     *AdvancedWaitGroup
+	tracer trace.Tracer
+
+	lock sync.Mutex
+	runs map[*runSettings]*otelRun
+}
+
+// otelRun keeps spans of one run, tasks abandoned by the run may finish
+// when the next run is started
+type otelRun struct {
+	ctx      context.Context
+	group    trace.Span
+	tasks    map[int]trace.Span
+	names    []string
+	finished bool
+}
+
+func (o *otelObserver) setting() string { return "otel" }
+
+func (o *otelObserver) groupStarted() {
+	parent := o.wg.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, span := o.tracer.Start(parent, "awg.group")
+
+	names := make([]string, len(o.wg.details))
+	for i, d := range o.wg.details {
+		names[i] = d.name
+	}
+
+	o.lock.Lock()
+	if o.runs == nil {
+		o.runs = map[*runSettings]*otelRun{}
+	}
+	o.runs[o.wg.currentRun()] = &otelRun{ctx: ctx, group: span, tasks: map[int]trace.Span{}, names: names}
+	o.lock.Unlock()
+}
+
+func (o *otelObserver) groupFinished(status int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	settings := o.wg.currentRun()
+	run, ok := o.runs[settings]
+	if !ok {
+		return
+	}
+
+	run.group.SetAttributes(attribute.Int("awg.status", status))
+	if status != StatusSuccess {
+		run.group.SetStatus(codes.Error, "")
+	}
+	run.group.End()
+
+	run.finished = true
+	if len(run.tasks) == 0 {
+		delete(o.runs, settings)
+	}
+}
+
+func (o *otelObserver) taskStarted(settings *runSettings, index int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	run, ok := o.runs[settings]
+	if !ok {
+		return
+	}
+
+	name := fmt.Sprintf("awg.task.%d", index)
+	if index < len(run.names) && run.names[index] != "" {
+		name = run.names[index]
+	}
+	_, span := o.tracer.Start(run.ctx, name)
+	run.tasks[index] = span
+}
+
+func (o *otelObserver) taskContext(ctx context.Context, settings *runSettings, index int) context.Context {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if run, ok := o.runs[settings]; ok {
+		if span, ok := run.tasks[index]; ok {
+			return trace.ContextWithSpan(ctx, span)
+		}
+	}
+	return ctx
+}
+
+func (o *otelObserver) taskFinished(settings *runSettings, index int, _ time.Duration, err error, panicked bool) {
+	o.lock.Lock()
+	run, ok := o.runs[settings]
+	var span trace.Span
+	if ok {
+		span = run.tasks[index]
+		delete(run.tasks, index)
+		if run.finished && len(run.tasks) == 0 {
+			delete(o.runs, settings)
+		}
+	}
+	o.lock.Unlock()
+
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if panicked {
+		span.SetAttributes(attribute.Bool("awg.panic", true))
+	}
+	span.End()
+}
+
+// WithSpan makes task that runs f in a span named spanName started by tracer.
+// Span gets error status if f fails. It doesn't need waitgroup tracer
+func WithSpan(f WaitgroupFunc, spanName string, tracer trace.Tracer) WaitgroupFunc {
+	return func() error {
+		_, span := tracer.Start(context.Background(), spanName)
+		defer span.End()
+
+		defer func() {
+			if r := recover(); r != nil {
+				span.SetAttributes(attribute.Bool("awg.panic", true))
+				span.SetStatus(codes.Error, fmt.Sprint(r))
+				panic(r)
+			}
+		}()
+
+		err := f()
+		span.SetAttributes(attribute.Bool("awg.failed", err != nil))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
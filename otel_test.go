@@ -0,0 +1,166 @@
+//go:build otel
+
+package awg
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Test_SetOpenTelemetryTracer test
+func Test_SetOpenTelemetryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, errorFunc)
+	wg.SetOpenTelemetryTracer(provider.Tracer("awg")).Start()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+
+	group, ok := spans["awg.group"]
+	if !ok || len(spans) != 3 {
+		t.Fatalf("Should record group span and span per task, got %v", spans)
+	}
+
+	for _, name := range []string{"awg.task.0", "awg.task.1"} {
+		if spans[name].Parent().SpanID() != group.SpanContext().SpanID() {
+			t.Errorf("Task span %s should be child of group span", name)
+		}
+	}
+
+	if spans["awg.task.1"].Status().Code != codes.Error {
+		t.Error("Failed task span should have error status")
+	}
+}
+
+// Test_SetOpenTelemetryTracerTaskSpan test
+func Test_SetOpenTelemetryTracerTaskSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var wg AdvancedWaitGroup
+	var got trace.SpanContext
+	wg.AddCtx(func(ctx context.Context) error {
+		got = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+	wg.AddNamed(NamedTask{Name: "fetch", F: fastFunc})
+	wg.SetOpenTelemetryTracer(provider.Tracer("awg")).Start()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+
+	task, ok := spans["awg.task.0"]
+	if !ok || got.SpanID() != task.SpanContext().SpanID() {
+		t.Errorf("Task added by AddCtx should get its span in context, got %v", got.SpanID())
+	}
+
+	if _, ok := spans["fetch"]; !ok {
+		t.Errorf("Named task span should be named by task, got %v", spans)
+	}
+}
+
+// Test_SetOpenTelemetryTracerAbandonedTask test
+func Test_SetOpenTelemetryTracerAbandonedTask(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var wg AdvancedWaitGroup
+	var calls int32
+	release := make(chan struct{})
+	wg.Add(func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+		return nil
+	})
+	wg.SetOpenTelemetryTracer(provider.Tracer("awg")).SetTimeout(10 * time.Millisecond).Start()
+
+	if wg.Restart().Status() != StatusSuccess {
+		t.Fatal("Second run should succeed")
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Ended()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	groups := map[trace.SpanID]bool{}
+	var parents []trace.SpanID
+	for _, s := range recorder.Ended() {
+		switch s.Name() {
+		case "awg.group":
+			groups[s.SpanContext().SpanID()] = true
+		case "awg.task.0":
+			parents = append(parents, s.Parent().SpanID())
+		}
+	}
+
+	if len(parents) != 2 || parents[0] == parents[1] || !groups[parents[0]] || !groups[parents[1]] {
+		t.Errorf("Each run should end its own task span, got parents %v", parents)
+	}
+}
+
+// Test_WithSpan test
+func Test_WithSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("awg")
+
+	var wg AdvancedWaitGroup
+	wg.Add(WithSpan(fastFunc, "fast", tracer), Retry(WithSpan(errorFunc, "failed", tracer), 2))
+	wg.Start()
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = append(spans[s.Name()], s)
+	}
+
+	if len(spans["fast"]) != 1 || spans["fast"][0].Status().Code == codes.Error {
+		t.Errorf("Should record successful span, got %v", spans["fast"])
+	}
+
+	if len(spans["failed"]) != 2 || spans["failed"][1].Status().Code != codes.Error {
+		t.Errorf("Should record failed span per attempt, got %v", spans["failed"])
+	}
+}
+
+// Test_SetOpenTelemetryTracerReplace test
+func Test_SetOpenTelemetryTracerReplace(t *testing.T) {
+	first := tracetest.NewSpanRecorder()
+	second := tracetest.NewSpanRecorder()
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc)
+	wg.SetOpenTelemetryTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(first)).Tracer("awg"))
+	wg.SetOpenTelemetryTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(second)).Tracer("awg"))
+	wg.Start()
+
+	if spans := first.Ended(); len(spans) != 0 {
+		t.Errorf("Replaced tracer shouldn't record spans, got %d", len(spans))
+	}
+	if spans := second.Ended(); len(spans) != 2 {
+		t.Errorf("Should record group span and task span once, got %d", len(spans))
+	}
+
+	wg.Reset()
+	wg.Add(fastFunc)
+	wg.Start()
+
+	if spans := second.Ended(); len(spans) != 2 {
+		t.Errorf("Reset should remove tracer, got %d spans", len(spans))
+	}
+}
@@ -0,0 +1,137 @@
+package awg
+
+import (
+	"sync"
+)
+
+// WorkerPool runs tasks of submitted waitgroups with fixed set of goroutines,
+// so goroutines are not spawned per task
+type WorkerPool struct {
+	queue     []poolJob
+	queueLock sync.Mutex
+	ready     *sync.Cond
+	quit      bool
+	groups    sync.WaitGroup
+	workers   sync.WaitGroup
+	lock      sync.RWMutex
+	shutdown  bool
+}
+
+// poolJob is a task queued to pool, abandon is called instead of run
+// if stop is closed before a worker takes the job
+type poolJob struct {
+	run     func()
+	abandon func()
+	stop    <-chan struct{}
+}
+
+// Future is the result of waitgroup submitted to WorkerPool
+type Future struct {
+	wg   *AdvancedWaitGroup
+	done chan struct{}
+}
+
+// NewPool makes pool of maxWorkers goroutines (at least one)
+func NewPool(maxWorkers int) *WorkerPool {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+
+	p := &WorkerPool{}
+	p.ready = sync.NewCond(&p.queueLock)
+
+	p.workers.Add(maxWorkers)
+	for i := 0; i < maxWorkers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit starts wg with tasks run by pool workers. Only the submitted run
+// uses the pool, later runs (e.g. Restart) spawn goroutines as usual.
+// It panics if pool is shut down
+func (p *WorkerPool) Submit(wg *AdvancedWaitGroup) *Future {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.shutdown {
+		panic("awg: submit to shut down pool")
+	}
+
+	f := &Future{done: make(chan struct{})}
+	wg.pool = p
+
+	p.groups.Add(1)
+	go func() {
+		defer p.groups.Done()
+		defer close(f.done)
+		f.wg = wg.Start()
+		wg.pool = nil
+	}()
+	return f
+}
+
+// Shutdown waits for submitted waitgroups and stops pool workers
+func (p *WorkerPool) Shutdown() {
+	p.lock.Lock()
+	if p.shutdown {
+		p.lock.Unlock()
+		return
+	}
+	p.shutdown = true
+	p.lock.Unlock()
+
+	p.groups.Wait()
+	p.queueLock.Lock()
+	p.quit = true
+	p.queueLock.Unlock()
+	p.ready.Broadcast()
+	p.workers.Wait()
+}
+
+// work runs queued jobs until shutdown, jobs left in queue are done first
+func (p *WorkerPool) work() {
+	defer p.workers.Done()
+
+	for {
+		p.queueLock.Lock()
+		for len(p.queue) == 0 && !p.quit {
+			p.ready.Wait()
+		}
+		if len(p.queue) == 0 {
+			p.queueLock.Unlock()
+			return
+		}
+		job := p.queue[0]
+		p.queue[0] = poolJob{}
+		p.queue = p.queue[1:]
+		p.queueLock.Unlock()
+
+		select {
+		case <-job.stop:
+			job.abandon()
+		default:
+			job.run()
+		}
+	}
+}
+
+// exec queues job, abandon is called instead if stop is closed before
+// a worker takes it. It doesn't block, jobs wait in the pool queue
+func (p *WorkerPool) exec(job, abandon func(), stop <-chan struct{}) {
+	p.queueLock.Lock()
+	p.queue = append(p.queue, poolJob{run: job, abandon: abandon, stop: stop})
+	p.queueLock.Unlock()
+	p.ready.Signal()
+}
+
+// Done returns channel that is closed when waitgroup finishes
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for waitgroup and returns it
+func (f *Future) Wait() *AdvancedWaitGroup {
+	<-f.done
+	return f.wg
+}
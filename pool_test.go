@@ -0,0 +1,98 @@
+package awg
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test_WorkerPool test
+func Test_WorkerPool(t *testing.T) {
+	pool := NewPool(2)
+	defer pool.Shutdown()
+
+	var probe concurrencyProbe
+	var wg1, wg2 AdvancedWaitGroup
+	wg1.Add(probe.task, probe.task, probe.task, errorFunc)
+	wg2.Add(probe.task, probe.task, probe.task)
+
+	future1, future2 := pool.Submit(&wg1), pool.Submit(&wg2)
+
+	if errs := future1.Wait().GetAllErrors(); len(errs) != 1 {
+		t.Errorf("Should get one error! Got %v", errs)
+	}
+
+	<-future2.Done()
+	if future2.Wait().Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+
+	if probe.max > 2 {
+		t.Errorf("Pool of 2 workers runs %d tasks at once", probe.max)
+	}
+}
+
+// Test_WorkerPoolGoroutines test
+func Test_WorkerPoolGoroutines(t *testing.T) {
+	pool := NewPool(4)
+	defer pool.Shutdown()
+
+	before := runtime.NumGoroutine()
+	var lock sync.Mutex
+	max := 0
+	var wg AdvancedWaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(func() error {
+			lock.Lock()
+			if n := runtime.NumGoroutine(); n > max {
+				max = n
+			}
+			lock.Unlock()
+			return nil
+		})
+	}
+	pool.Submit(&wg).Wait()
+
+	if max > before+10 || wg.GetStats().Success != 1000 {
+		t.Errorf("Busy pool should not spawn goroutines per task, got %d goroutines (%d before)", max, before)
+	}
+}
+
+// Test_WorkerPoolShutdown test
+func Test_WorkerPoolShutdown(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	pool := NewPool(10)
+	var wg AdvancedWaitGroup
+	future := pool.Submit(wg.MustAdd(fastFunc))
+	pool.Shutdown()
+
+	select {
+	case <-future.Done():
+	default:
+		t.Error("Shutdown should wait for submitted groups")
+	}
+
+	if runtime.NumGoroutine() > before {
+		t.Errorf("Pool workers should stop after shutdown")
+	}
+
+	restarted := make(chan struct{})
+	go func() {
+		defer close(restarted)
+		wg.Restart()
+	}()
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		t.Fatal("Restart after shutdown should not use the pool")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Submit to shut down pool should panic")
+		}
+	}()
+	pool.Submit(&wg)
+}
@@ -0,0 +1,83 @@
+//go:build profiling
+
+package awg
+
+import (
+	"io"
+	"log"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// SetCPUProfile makes waitgroup write CPU profile to w while Start runs.
+// The next call replaces w, Reset removes the profile.
+// It is development tool, available with profiling build tag only
+func (wg *AdvancedWaitGroup) SetCPUProfile(w io.Writer) *AdvancedWaitGroup {
+	wg.setObserver(&cpuProfiler{w: w})
+	return wg
+}
+
+// cpuProfiler profiles CPU from start to finish of waitgroup run
+type cpuProfiler struct {
+	w       io.Writer
+	started bool
+}
+
+func (p *cpuProfiler) setting() string { return "cpuprofile" }
+
+func (p *cpuProfiler) groupStarted() {
+	if err := pprof.StartCPUProfile(p.w); err != nil {
+		log.Printf("awg: CPU profile is not started: %v", err)
+		return
+	}
+	p.started = true
+}
+
+func (p *cpuProfiler) groupFinished(int) {
+	if p.started {
+		pprof.StopCPUProfile()
+		p.started = false
+	}
+}
+
+func (p *cpuProfiler) taskStarted(*runSettings, int) {}
+
+func (p *cpuProfiler) taskFinished(*runSettings, int, time.Duration, error, bool) {}
+
+// SetMemProfile makes waitgroup sample memory allocations with rate (see
+// runtime.MemProfileRate) while Start runs and write heap profile to w when
+// it finishes. It can be used along with SetCPUProfile. The next call
+// replaces w and rate, Reset removes the profile.
+// It is development tool, available with profiling build tag only
+func (wg *AdvancedWaitGroup) SetMemProfile(w io.Writer, rate int) *AdvancedWaitGroup {
+	wg.setObserver(&memProfiler{w: w, rate: rate})
+	return wg
+}
+
+// memProfiler samples allocations from start to finish of waitgroup run
+type memProfiler struct {
+	w        io.Writer
+	rate     int
+	prevRate int
+}
+
+func (p *memProfiler) setting() string { return "memprofile" }
+
+func (p *memProfiler) groupStarted() {
+	p.prevRate = runtime.MemProfileRate
+	runtime.MemProfileRate = p.rate
+}
+
+func (p *memProfiler) groupFinished(int) {
+	// Heap profile is up to date as of the last GC
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(p.w); err != nil {
+		log.Printf("awg: heap profile is not written: %v", err)
+	}
+	runtime.MemProfileRate = p.prevRate
+}
+
+func (p *memProfiler) taskStarted(*runSettings, int) {}
+
+func (p *memProfiler) taskFinished(*runSettings, int, time.Duration, error, bool) {}
@@ -0,0 +1,82 @@
+//go:build profiling
+
+package awg
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Test_SetCPUProfile test
+func Test_SetCPUProfile(t *testing.T) {
+	var buf bytes.Buffer
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, sleepFunc)
+	wg.SetCPUProfile(&buf).Start()
+
+	if wg.Status() != StatusSuccess {
+		t.Error("AWG result should be 'success'!")
+	}
+
+	if buf.Len() == 0 {
+		t.Error("CPU profile should be written")
+	}
+}
+
+// Test_SetCPUProfileReplace test
+func Test_SetCPUProfileReplace(t *testing.T) {
+	var first, second bytes.Buffer
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, sleepFunc)
+	wg.SetCPUProfile(&first).SetCPUProfile(&second).Start()
+
+	if first.Len() != 0 || second.Len() == 0 {
+		t.Errorf("Profile should be written to the last writer only, got %d and %d bytes", first.Len(), second.Len())
+	}
+
+	wg.Reset()
+	second.Reset()
+	wg.Add(fastFunc)
+	wg.Start()
+
+	if second.Len() != 0 {
+		t.Error("Reset should remove CPU profile")
+	}
+}
+
+// Test_SetMemProfile test
+func Test_SetMemProfile(t *testing.T) {
+	var cpu, mem bytes.Buffer
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc, sleepFunc)
+	wg.SetCPUProfile(&cpu).SetMemProfile(&mem, 1).Start()
+
+	if cpu.Len() == 0 || mem.Len() == 0 {
+		t.Errorf("Both profiles should be written, got %d and %d bytes", cpu.Len(), mem.Len())
+	}
+}
+
+// Test_SetMemProfileReplace test
+func Test_SetMemProfileReplace(t *testing.T) {
+	var first, second bytes.Buffer
+
+	var wg AdvancedWaitGroup
+	wg.Add(fastFunc)
+	wg.SetMemProfile(&first, 1).SetMemProfile(&second, 1).Start()
+
+	if first.Len() != 0 || second.Len() == 0 {
+		t.Errorf("Profile should be written to the last writer only, got %d and %d bytes", first.Len(), second.Len())
+	}
+
+	wg.Reset()
+	second.Reset()
+	wg.Add(fastFunc)
+	wg.Start()
+
+	if second.Len() != 0 {
+		t.Error("Reset should remove heap profile")
+	}
+}
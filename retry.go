@@ -0,0 +1,168 @@
+package awg
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how waitgroup retries failed tasks
+type RetryPolicy struct {
+	// Attempts is the maximum number of task runs, so 1 means no retries
+	Attempts int
+	// Backoff is the pause between attempts
+	Backoff time.Duration
+}
+
+// retrier retries failed tasks of waitgroup
+type retrier struct {
+	policy    RetryPolicy
+	policySet bool
+	condition func(error) bool
+	jitter    time.Duration
+	initial   time.Duration
+	max       time.Duration
+	seed      int64
+}
+
+// SetRetryPolicy makes waitgroup run failed tasks again by policy.
+// Panicked tasks are not retried
+func (wg *AdvancedWaitGroup) SetRetryPolicy(policy RetryPolicy) *AdvancedWaitGroup {
+	if wg.retrier == nil {
+		wg.retrier = &retrier{}
+	}
+	wg.retrier.policy = policy
+	wg.retrier.policySet = true
+	return wg
+}
+
+// SetRetryCondition makes retry policy retry only errors that satisfy fn.
+// Any error is retried by default
+func (wg *AdvancedWaitGroup) SetRetryCondition(fn func(error) bool) *AdvancedWaitGroup {
+	if wg.retrier == nil {
+		wg.retrier = &retrier{}
+	}
+	wg.retrier.condition = fn
+	return wg
+}
+
+// SetRetryJitter adds random pause in [0, maxJitter) to retry backoff.
+// Pauses are reproducible if SetSeed is called. It panics if retry policy
+// is not set
+func (wg *AdvancedWaitGroup) SetRetryJitter(maxJitter time.Duration) *AdvancedWaitGroup {
+	if !wg.hasRetryPolicy() {
+		panic("awg: SetRetryJitter is called without retry policy")
+	}
+	wg.retrier.jitter = maxJitter
+	return wg
+}
+
+// SetInitialBackoff makes retry backoff exponential: the first retry waits d,
+// every next one waits twice as long (see SetMaxBackoff). It replaces
+// backoff of retry policy. It panics if retry policy is not set
+func (wg *AdvancedWaitGroup) SetInitialBackoff(d time.Duration) *AdvancedWaitGroup {
+	if !wg.hasRetryPolicy() {
+		panic("awg: SetInitialBackoff is called without retry policy")
+	}
+	wg.retrier.initial = d
+	return wg
+}
+
+// SetMaxBackoff limits exponential backoff set by SetInitialBackoff.
+// Zero means no limit (default). It panics if retry policy is not set
+func (wg *AdvancedWaitGroup) SetMaxBackoff(d time.Duration) *AdvancedWaitGroup {
+	if !wg.hasRetryPolicy() {
+		panic("awg: SetMaxBackoff is called without retry policy")
+	}
+	wg.retrier.max = d
+	return wg
+}
+
+// hasRetryPolicy reports whether SetRetryPolicy is called, retrier made by
+// SetRetryCondition alone doesn't retry
+func (wg *AdvancedWaitGroup) hasRetryPolicy() bool {
+	return wg.retrier != nil && wg.retrier.policySet
+}
+
+// clone returns copy of retry policy with seed of jitter
+func (r *retrier) clone(seed int64) *retrier {
+	return &retrier{
+		policy:    r.policy,
+		policySet: r.policySet,
+		condition: r.condition,
+		jitter:    r.jitter,
+		initial:   r.initial,
+		max:       r.max,
+		seed:      seed,
+	}
+}
+
+// taskRandom returns source of jitter of i-th task, so jitter of a task
+// doesn't depend on other tasks
+func (r *retrier) taskRandom(index int) *rand.Rand {
+	return rand.New(rand.NewSource(r.seed + int64(index)))
+}
+
+// callWithRetry executes task retrying it by retry policy of its run
+func (wg *AdvancedWaitGroup) callWithRetry(t task) (err error, panicked bool) {
+	err, panicked = wg.call(t.f)
+	retrier := t.settings.retrier
+	if retrier == nil {
+		return err, panicked
+	}
+
+	ctx := t.settings.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var random *rand.Rand
+	for attempt := 1; retrier.shouldRetry(attempt, err, panicked); attempt++ {
+		if random == nil && retrier.jitter > 0 {
+			random = retrier.taskRandom(t.index)
+		}
+		if !sleep(ctx, retrier.delay(attempt, random)) {
+			break
+		}
+		err, panicked = wg.call(t.f)
+	}
+	return err, panicked
+}
+
+// shouldRetry reports whether task failed after attempt runs should run again
+func (r *retrier) shouldRetry(attempt int, err error, panicked bool) bool {
+	if err == nil || panicked || attempt >= r.policy.Attempts {
+		return false
+	}
+	return r.condition == nil || r.condition(err)
+}
+
+// delay returns pause before the next attempt after attempt runs,
+// jitter is drawn from random
+func (r *retrier) delay(attempt int, random *rand.Rand) time.Duration {
+	d := r.backoff(attempt)
+	if r.jitter <= 0 {
+		return d
+	}
+	return d + time.Duration(random.Int63n(int64(r.jitter)))
+}
+
+// backoff returns pause without jitter before the next attempt
+func (r *retrier) backoff(attempt int) time.Duration {
+	if r.initial <= 0 {
+		return r.policy.Backoff
+	}
+
+	d := r.initial
+	for i := 1; i < attempt && d < math.MaxInt64/2; i++ {
+		if r.max > 0 && d >= r.max {
+			break
+		}
+		d *= 2
+	}
+	if r.max > 0 && d > r.max {
+		d = r.max
+	}
+	return d
+}
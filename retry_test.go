@@ -0,0 +1,191 @@
+package awg
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTestFatal = errors.New("Test fatal error")
+
+// Test_AdvancedWorkGroupSetRetryPolicy test
+func Test_AdvancedWorkGroupSetRetryPolicy(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	f, calls := failingFunc(2)
+	wg.Add(f)
+	wg.SetRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Millisecond}).Start()
+
+	if wg.Status() != StatusSuccess || len(wg.GetAllErrors()) != 0 {
+		t.Errorf("Retried task should succeed, got %v", wg.GetAllErrors())
+	}
+
+	if *calls != 3 {
+		t.Errorf("Task should run 3 times, got %d", *calls)
+	}
+}
+
+// Test_AdvancedWorkGroupSetRetryPolicyAfterReset test
+func Test_AdvancedWorkGroupSetRetryPolicyAfterReset(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	calls := make(chan int, 2)
+	n := 0
+	wg.Add(func() error {
+		n++
+		calls <- n
+		if n == 1 {
+			time.Sleep(50 * time.Millisecond)
+			return errors.New("Test error")
+		}
+		return nil
+	})
+	wg.SetRetryPolicy(RetryPolicy{Attempts: 2}).SetTimeout(10 * time.Millisecond).Start()
+	wg.Reset()
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatalf("Task outliving its run should be retried by policy of the run, got %d runs", i-1)
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupSetRetryCondition test
+func Test_AdvancedWorkGroupSetRetryCondition(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	calls := 0
+	wg.Add(func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("Test error")
+		}
+		return errTestFatal
+	})
+	wg.SetRetryPolicy(RetryPolicy{Attempts: 5}).SetRetryCondition(func(err error) bool {
+		return err != errTestFatal
+	}).Start()
+
+	if calls != 2 {
+		t.Errorf("Task should not be retried after fatal error, got %d runs", calls)
+	}
+
+	if err := wg.GetLastError(); err != errTestFatal {
+		t.Errorf("Should get fatal error, got %v", err)
+	}
+}
+
+// Test_AdvancedWorkGroupSetRetryJitter test
+func Test_AdvancedWorkGroupSetRetryJitter(t *testing.T) {
+	var wg1, wg2 AdvancedWaitGroup
+
+	retriers := make([]*retrier, 2)
+	for i, wg := range []*AdvancedWaitGroup{&wg1, &wg2} {
+		f, _ := failingFunc(1)
+		wg.MustAdd(f).SetSeed(42).SetRetryPolicy(RetryPolicy{Attempts: 2, Backoff: time.Millisecond}).
+			SetRetryJitter(10 * time.Millisecond).Start()
+		// Retry policy of a run is cloned the same way
+		retriers[i] = wg.retrier.clone(wg.random().Int63())
+	}
+
+	r1, r2 := retriers[0].taskRandom(0), retriers[1].taskRandom(0)
+	for i := 0; i < 10; i++ {
+		d1, d2 := retriers[0].delay(1, r1), retriers[1].delay(1, r2)
+		if d1 != d2 {
+			t.Fatalf("Jitter should be reproducible with seed, got %v and %v", d1, d2)
+		}
+		if d1 < time.Millisecond || d1 >= 11*time.Millisecond {
+			t.Fatalf("Delay should be backoff plus jitter, got %v", d1)
+		}
+	}
+}
+
+// Test_AdvancedWorkGroupSetRetryJitterWithoutPolicy test
+func Test_AdvancedWorkGroupSetRetryJitterWithoutPolicy(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Should panic without retry policy")
+		}
+	}()
+
+	wg.SetRetryJitter(time.Millisecond)
+}
+
+// Test_AdvancedWorkGroupSetRetryJitterAfterCondition test
+func Test_AdvancedWorkGroupSetRetryJitterAfterCondition(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Should panic if only retry condition is set")
+		}
+	}()
+
+	wg.SetRetryCondition(func(error) bool { return true }).SetRetryJitter(time.Millisecond)
+}
+
+// Test_AdvancedWorkGroupSetInitialBackoff test
+func Test_AdvancedWorkGroupSetInitialBackoff(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.SetRetryPolicy(RetryPolicy{Attempts: 10, Backoff: time.Second}).
+		SetInitialBackoff(10 * time.Millisecond).SetMaxBackoff(50 * time.Millisecond)
+
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, d := range want {
+		if got := wg.retrier.delay(i+1, nil); got != d*time.Millisecond {
+			t.Errorf("Delay after %d attempts should be %v, got %v", i+1, d*time.Millisecond, got)
+		}
+	}
+
+	f, calls := failingFunc(3)
+	start := time.Now()
+	wg.MustAdd(f).Start()
+
+	if *calls != 4 || time.Since(start) < 70*time.Millisecond {
+		t.Errorf("Task should be retried with exponential backoff, got %d calls in %v", *calls, time.Since(start))
+	}
+
+	wg.SetMaxBackoff(0)
+	if got := wg.retrier.delay(62, nil); got <= 0 {
+		t.Errorf("Unlimited backoff should not overflow, got %v", got)
+	}
+}
+
+// Test_AdvancedWorkGroupSetInitialBackoffWithoutPolicy test
+func Test_AdvancedWorkGroupSetInitialBackoffWithoutPolicy(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Should panic without retry policy")
+		}
+	}()
+
+	wg.SetInitialBackoff(time.Millisecond)
+}
+
+// Test_AdvancedWorkGroupSetBackoffAfterCondition test
+func Test_AdvancedWorkGroupSetBackoffAfterCondition(t *testing.T) {
+	for name, set := range map[string]func(wg *AdvancedWaitGroup){
+		"SetInitialBackoff": func(wg *AdvancedWaitGroup) { wg.SetInitialBackoff(time.Millisecond) },
+		"SetMaxBackoff":     func(wg *AdvancedWaitGroup) { wg.SetMaxBackoff(time.Millisecond) },
+	} {
+		func() {
+			var wg AdvancedWaitGroup
+			wg.SetRetryCondition(func(error) bool { return true })
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s should panic if only retry condition is set", name)
+				}
+			}()
+
+			set(&wg)
+		}()
+	}
+}
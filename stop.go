@@ -0,0 +1,45 @@
+package awg
+
+import (
+	"sync"
+)
+
+// running groups registered by RegisterForGlobalStop
+var (
+	runningGroups     = map[*AdvancedWaitGroup]struct{}{}
+	runningGroupsLock sync.Mutex
+)
+
+// RegisterForGlobalStop makes StopAll cancel wg while it runs (until Reset).
+// It should be called before Start. Groups are not registered by default
+func RegisterForGlobalStop(wg *AdvancedWaitGroup) {
+	wg.globalStop = true
+}
+
+// StopAll cancels all running groups registered by RegisterForGlobalStop,
+// e.g. on graceful shutdown
+func StopAll() {
+	runningGroupsLock.Lock()
+	groups := make([]*AdvancedWaitGroup, 0, len(runningGroups))
+	for wg := range runningGroups {
+		groups = append(groups, wg)
+	}
+	runningGroupsLock.Unlock()
+
+	for _, wg := range groups {
+		wg.Cancel()
+	}
+}
+
+func register(wg *AdvancedWaitGroup) {
+	runningGroupsLock.Lock()
+	runningGroups[wg] = struct{}{}
+	runningGroupsLock.Unlock()
+}
+
+// unregister drops finished group, so registry keeps no references to it
+func unregister(wg *AdvancedWaitGroup) {
+	runningGroupsLock.Lock()
+	delete(runningGroups, wg)
+	runningGroupsLock.Unlock()
+}
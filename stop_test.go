@@ -0,0 +1,76 @@
+package awg
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Test_StopAll test
+func Test_StopAll(t *testing.T) {
+	var registered, other AdvancedWaitGroup
+
+	hung := make(chan struct{})
+	defer close(hung)
+	hungFunc := func() error {
+		<-hung
+		return nil
+	}
+
+	registered.Add(hungFunc)
+	RegisterForGlobalStop(&registered)
+	other.Add(sleepFunc)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		StopAll()
+	}()
+
+	result := All(&registered, &other)
+
+	if registered.Status() != StatusCancelled || registered.GetLastError() != context.Canceled {
+		t.Errorf("Registered group should be cancelled, got %v", registered.GetAllErrors())
+	}
+
+	if other.Status() != StatusSuccess {
+		t.Error("Not registered group shouldn`t be cancelled")
+	}
+
+	if result.Status() != StatusCancelled {
+		t.Error("All result should be 'cancelled'!")
+	}
+
+	runningGroupsLock.Lock()
+	defer runningGroupsLock.Unlock()
+	if len(runningGroups) != 0 {
+		t.Error("Finished groups should be unregistered")
+	}
+}
+
+// Test_StopAllAfterReset test
+func Test_StopAllAfterReset(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	RegisterForGlobalStop(&wg)
+	wg.Reset()
+	wg.Add(sleepFunc)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		StopAll()
+	}()
+
+	if wg.Start().Status() != StatusSuccess {
+		t.Error("Reset group shouldn`t be cancelled by StopAll")
+	}
+}
+
+// Test_AdvancedWorkGroupCancelIdle test
+func Test_AdvancedWorkGroupCancelIdle(t *testing.T) {
+	var wg AdvancedWaitGroup
+
+	wg.Cancel()
+	if wg.MustAdd(fastFunc).Start().Status() != StatusSuccess {
+		t.Error("Cancel of not running group should be ignored")
+	}
+}